@@ -3,24 +3,47 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Embedded zone database for cron trigger timezones
 
 	"go.uber.org/zap"
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
 	"github.com/go-chi/chi/v5/middleware"
 	"path/filepath"
 	"github.com/joho/godotenv"
 
 	"XKA/pkg/RedisClient"
+	"XKA/pkg/ids"
 	"XKA/pkg/logger"
 	"XKA/internal/shared/builder"
+	"XKA/internal/shared/control"
+	"XKA/internal/shared/nodetypes"
+	"XKA/internal/shared/queue"
+	"XKA/internal/shared/runlog"
+	"XKA/internal/shared/runs"
+	"XKA/internal/shared/schema"
+	"XKA/internal/shared/storage"
+	"XKA/internal/shared/waits"
+	"XKA/internal/worker-manager/activation"
+	"XKA/internal/worker-manager/definition"
+	"XKA/internal/worker-manager/event"
 	"XKA/internal/worker-manager/parser"
+	"XKA/internal/worker-manager/retry"
+	"XKA/internal/worker-manager/scheduler"
+	"XKA/internal/worker-manager/session"
+	"XKA/internal/worker-manager/webhook"
 
 )
 
@@ -31,7 +54,12 @@ const (
 	ReadTimeout        = 15 * time.Second
 	WriteTimeout       = 15 * time.Second
 	IdleTimeout        = 60 * time.Second
+	RequestTimeout     = 30 * time.Second
+	StreamHeartbeat    = 15 * time.Second // Keep-alive interval of event streams
+	MaxRunWait         = 2 * time.Minute  // Longest ?wait= accepted by run endpoints
 	MaxRequestBodySize = 10 << 20 // 10MB
+	DefaultPageSize    = 20
+	MaxPageSize        = 100
 )
 
 // APIResponse represents a standardized API response structure
@@ -53,6 +81,9 @@ type Server struct {
 	router *chi.Mux
 	logger *zap.Logger
 	port   string
+	cron   *scheduler.CronScheduler
+	// upgrader opens interactive editor sessions
+	upgrader *websocket.Upgrader
 }
 
 // NewServer creates a new server instance with proper configuration
@@ -60,6 +91,8 @@ func NewServer() *Server {
 	return &Server{
 		logger: logger.Log,
 		port:   getPort(),
+		cron:   scheduler.NewCronScheduler(RedisClient.GetClient(), scheduler.DefaultCronInterval),
+		upgrader: session.NewUpgrader(),
 	}
 }
 
@@ -83,8 +116,6 @@ func (s *Server) setupRoutes() {
 	r.Use(middleware.Heartbeat("/health")) // Health check endpoint
 
 	// Security and performance middleware
-	r.Use(middleware.AllowContentType("application/json"))
-	r.Use(middleware.Timeout(30 * time.Second))
 	r.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Limit request body size to prevent abuse
@@ -95,239 +126,2325 @@ func (s *Server) setupRoutes() {
 
 	// API routes with versioning
 	r.Route("/api", func(r chi.Router) {
+		r.Use(middleware.AllowContentType("application/json"))
+
 		r.Route("/v1", func(r chi.Router) {
-			r.Post("/workflow", s.handleWorkflowSubmission)
-			r.Post("/workflow/validate", s.handleWorkflowValidation)
-			r.Get("/workflow/{id}", s.handleGetWorkflow)
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.Timeout(RequestTimeout))
+
+				r.Post("/workflow/validate", s.handleWorkflowValidation)
+				r.Get("/workflow/{id}", s.handleGetWorkflow)
+				r.Get("/workflow/{id}/runs", s.handleListWorkflowRuns)
+
+				r.Route("/workflows", func(r chi.Router) {
+					r.Post("/", s.handleCreateDefinition)
+					r.Get("/", s.handleListDefinitions)
+					r.Get("/{id}", s.handleGetDefinition)
+					r.Put("/{id}", s.handleUpdateDefinition)
+					r.Delete("/{id}", s.handleDeleteDefinition)
+					r.Post("/{id}/rollback", s.handleRollbackDefinition)
+					r.Get("/{id}/revisions", s.handleListRevisions)
+					r.Get("/{id}/revisions/{revision}", s.handleGetRevision)
+				})
+
+				r.Get("/runs/{runId}", s.handleGetRun)
+				r.Post("/runs/{runId}/events", s.handleDeliverRunEvent)
+
+				// Debugger commands: a paused run executes one node, runs to the next breakpoint or stops
+				r.Post("/runs/{runId}/step", s.handleRunCommand(control.ActionStep))
+				r.Post("/runs/{runId}/continue", s.handleRunCommand(control.ActionResume))
+				r.Post("/runs/{runId}/abort", s.handleRunCommand(control.ActionCancel))
+
+				// Operator pause: a paused run is suspended between nodes and resumed on any worker
+				r.Post("/runs/{runId}/pause", s.handleRunCommand(control.ActionPause))
+				r.Post("/runs/{runId}/resume", s.handleRunCommand(control.ActionResume))
+
+				// Queue pause: workers stop popping new jobs from a queue (or all of them)
+				r.Get("/queues", s.handleListQueues)
+				r.Post("/queues/{name}/pause", s.handleQueueCommand(true))
+				r.Post("/queues/{name}/resume", s.handleQueueCommand(false))
+
+				// Node type catalog and configuration schemas, for the editor palette and forms
+				r.Get("/node-types", s.handleListNodeTypes)
+				r.Get("/node-types/{type}/schema", s.handleGetNodeTypeSchema)
+
+				r.Get("/scheduled", s.handleListScheduled)
+				r.Get("/scheduled/{id}", s.handleGetScheduled)
+				r.Delete("/scheduled/{id}", s.handleCancelScheduled)
+
+				r.Post("/activations", s.handleActivateWorkflow)
+				r.Get("/activations", s.handleListActivations)
+				r.Delete("/activations/{id}", s.handleDeactivateWorkflow)
+
+				r.Post("/events/{name}", s.handlePublishEvent)
+			})
+
+			// Long-lived routes stay open for the whole run: no request timeout
+			r.Group(func(r chi.Router) {
+				// Run submissions, answered once the run finishes when ?wait is set
+				r.Post("/workflow", s.handleWorkflowSubmission)
+				r.Post("/workflows/{id}/run", s.handleRunDefinition)
+				r.Post("/runs/{runId}/retry", s.handleRetryRun)
+
+				// Run event stream (Server-Sent Events)
+				r.Get("/runs/{runId}/events", s.handleRunEvents)
+
+				// Single node execution ("test this step")
+				r.Post("/nodes/execute", s.handleExecuteNode)
+
+				// Interactive editor sessions (WebSocket)
+				r.Get("/session", s.handleSession)
+			})
 		})
 	})
 
-	// Root and utility routes
-	r.Get("/", s.handleRoot)
-	r.Get("/version", s.handleVersion)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(RequestTimeout))
+
+		// Webhook triggers accept any content type
+		r.HandleFunc("/hooks/*", s.handleWebhook)
+
+		// Root and utility routes
+		r.Get("/", s.handleRoot)
+		r.Get("/version", s.handleVersion)
+	})
+
+	s.router = r
+}
+
+// handleRoot provides a welcome message and basic server info
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	response := APIResponse{
+		Status:  "success",
+		Message: "Worker Manager API Server",
+		Data: map[string]interface{}{
+			"version":   "1.0.5",
+			"endpoints": []string{"/api/v1/workflow", "/api/v1/workflows", "/health", "/version"},
+			"docs":      "Visit /api/v1/workflow for workflow processing",
+			"contact":   "For support, contact : https://github.com/MaXianbibi",
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleVersion returns server version information
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	response := APIResponse{
+		Status: "success",
+		Data: map[string]string{
+			"version":    "1.0.0",
+			"build_time": time.Now().Format(time.RFC3339),
+			"go_version": runtime.Version(),
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleWorkflowSubmission processes workflow parsing requests
+func (s *Server) handleWorkflowSubmission(w http.ResponseWriter, r *http.Request) {
+	// Extract request ID for correlation
+	requestID := middleware.GetReqID(r.Context())
+
+	s.logger.Info("Processing workflow submission",
+		zap.String("request_id", requestID),
+		zap.String("client_ip", r.RemoteAddr),
+	)
+
+	// Parse and validate request payload
+	var payload map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.logger.Warn("Invalid JSON payload received",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+
+	parsedWorkflow, runErr := s.parseSubmission(requestID, payload)
+	if runErr != nil {
+		s.writeRunError(w, runErr)
+		return
+	}
+
+	wait, err := parseWait(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid wait parameter", err.Error())
+		return
+	}
+
+	run, runErr := s.submitRun(requestID, payload["id"].(string), 0, parsedWorkflow, payload, nil)
+	s.writeRunSubmission(w, r, run, runErr, wait)
+}
+
+// parseSubmission validates and parses an ad-hoc workflow submission
+func (s *Server) parseSubmission(requestID string, payload map[string]interface{}) (*parser.Payload, *runError) {
+	// Basic payload validation
+	if err := s.validateWorkflowPayload(payload); err != nil {
+		s.logger.Warn("Workflow payload validation failed",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return nil, &runError{status: http.StatusBadRequest, message: "Invalid workflow payload", detail: err.Error()}
+	}
+
+	// Parse workflow using the parser package
+	parsedWorkflow, err := parser.ParseWorkflow(payload)
+	if err != nil {
+		s.logger.Error("Workflow parsing failed",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return nil, workflowError("Failed to parse workflow", err)
+	}
+	
+	// Log successful parsing with metrics
+	s.logger.Info("Workflow parsed successfully",
+		zap.String("request_id", requestID),
+		zap.Int("node_count", len(parsedWorkflow.Nodes)),
+		zap.Int("edge_count", len(parsedWorkflow.Edges)),
+	)
+
+	return parsedWorkflow, nil
+}
+
+// runError is a run submission failure, reported to the client as-is
+type runError struct {
+	status  int
+	message string
+	detail  string
+	fields  schema.Errors        // Field-level problems of the run input, if any
+	issues  []parser.Issue // Validation report of the workflow, if any
+}
+
+// workflowError reports a parser.ParseWorkflow or builder.InitWorkflow failure,
+// with every issue of the validation report
+func workflowError(message string, err error) *runError {
+	runErr := &runError{status: http.StatusUnprocessableEntity, message: message, detail: err.Error()}
+	var validationErr *parser.ValidationError
+	if errors.As(err, &validationErr) {
+		runErr.issues = validationErr.Issues
+	}
+	return runErr
+}
+
+// submittedRun is a run accepted for execution
+type submittedRun struct {
+	job      *queue.Job
+	deferred bool // Scheduled for later instead of queued
+	message  string
+	data     map[string]interface{} // Response data describing the run
+}
+
+// retryOrigin is the finished run a retry is created from
+type retryOrigin struct {
+	runID  string
+	from   string          // Node re-executed first
+	result json.RawMessage // Execution result of the original run
+}
+
+// writeRunSubmission answers a run submission with the run ID or the submission error.
+// With a non-zero wait, the answer is held until the run finishes and carries its
+// execution result; a run still in progress after wait is answered with 202.
+// Scheduled runs are never waited for. The wait ends early when the client goes away.
+func (s *Server) writeRunSubmission(w http.ResponseWriter, r *http.Request, run *submittedRun, runErr *runError, wait time.Duration) {
+	if runErr != nil {
+		s.writeRunError(w, runErr)
+		return
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: run.message,
+		Data:    run.data,
+	}
+
+	if wait == 0 || run.deferred {
+		s.writeJSONResponse(w, http.StatusCreated, response)
+		return
+	}
+
+	// Hold the response until the worker publishes the final result
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + WriteTimeout))
+	w.Header().Set("X-Run-ID", run.job.RunID)
+
+	rawResult, err := queue.WaitResult(r.Context(), run.job.RunID, wait)
+	if errors.Is(err, queue.ErrResultTimeout) {
+		response.Message = "Workflow run still in progress"
+		s.writeJSONResponse(w, http.StatusAccepted, response)
+		return
+	}
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to wait for workflow run", err.Error())
+		return
+	}
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	json.Unmarshal([]byte(rawResult), &result)
+
+	response.Message = "Workflow run completed"
+	if result.Status != string(runs.StatusSuccess) {
+		response.Message = fmt.Sprintf("Workflow run finished with status %s", result.Status)
+	}
+	response.Data = json.RawMessage(rawResult)
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// writeRunError answers with a submission error, field-level and graph problems included
+func (s *Server) writeRunError(w http.ResponseWriter, runErr *runError) {
+	if len(runErr.issues) > 0 {
+		s.writeJSONResponse(w, runErr.status, APIResponse{
+			Status:  "error",
+			Message: runErr.message,
+			Data:    validationReport(runErr.issues),
+			Error:   runErr.detail,
+		})
+		return
+	}
+	if len(runErr.fields) > 0 {
+		s.writeJSONResponse(w, runErr.status, APIResponse{
+			Status:  "error",
+			Message: runErr.message,
+			Data:    map[string]interface{}{"errors": runErr.fields},
+			Error:   runErr.detail,
+		})
+		return
+	}
+	s.writeErrorResponse(w, runErr.status, runErr.message, runErr.detail)
+}
+
+// validationReport describes the issues of a workflow for the editor:
+// every problem with its JSON pointer, code, severity and node or edge ID
+func validationReport(issues []parser.Issue) map[string]interface{} {
+	if issues == nil {
+		issues = []parser.Issue{}
+	}
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.Severity == parser.SeverityError {
+			errorCount++
+		}
+	}
+	return map[string]interface{}{
+		"valid":        errorCount == 0,
+		"errorCount":   errorCount,
+		"warningCount": len(issues) - errorCount,
+		"issues":       issues,
+	}
+}
+
+// parseBreakpoints reads the optional breakpoints run option: IDs of nodes a
+// debug run pauses before, in addition to the nodes flagged in the graph
+func parseBreakpoints(wf *builder.Workflow, mode string, raw interface{}) ([]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if mode != queue.ModeDebug {
+		return nil, fmt.Errorf("breakpoints require the debug mode")
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("breakpoints must be an array of node IDs")
+	}
+	breakpoints := make([]string, 0, len(list))
+	for _, item := range list {
+		id, ok := item.(string)
+		if !ok || wf.FindNodeByID(id) == nil {
+			return nil, fmt.Errorf("breakpoint %v is not a node of the workflow", item)
+		}
+		breakpoints = append(breakpoints, id)
+	}
+	return breakpoints, nil
+}
+
+// parseWait reads the optional ?wait= parameter of run submissions:
+// a Go duration ("30s") or a number of seconds, at most MaxRunWait
+func parseWait(r *http.Request) (time.Duration, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("wait"))
+	if raw == "" {
+		return 0, nil
+	}
+
+	wait, err := time.ParseDuration(raw)
+	if err != nil {
+		seconds, convErr := strconv.ParseFloat(raw, 64)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid wait %q (expected a duration like \"30s\" or a number of seconds)", raw)
+		}
+		wait = time.Duration(seconds * float64(time.Second))
+	}
+
+	if wait <= 0 {
+		return 0, fmt.Errorf("wait must be positive")
+	}
+	if wait > MaxRunWait {
+		return 0, fmt.Errorf("wait must not exceed %s", MaxRunWait)
+	}
+	return wait, nil
+}
+
+// submitRun initializes a parsed workflow and queues (or schedules) a run of it.
+// Run options (priority, mode, breakpoints, runAt/delay, triggerNodeId, input) are read from options.
+// The input is validated against the input schema of the start node.
+// Revision is the stored revision being run, 0 for ad-hoc submissions.
+// A non-nil origin makes the run a retry of a finished run.
+// The run is recorded and its job pushed (or scheduled) before returning.
+func (s *Server) submitRun(requestID, workflowID string, revision int, parsedWorkflow *parser.Payload, options map[string]interface{}, origin *retryOrigin) (*submittedRun, *runError) {
+	// Resolve the scheduling priority (defaults to normal)
+	priority, err := queue.ParsePriority(options["priority"])
+	if err != nil {
+		s.logger.Warn("Invalid workflow priority",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return nil, &runError{status: http.StatusBadRequest, message: "Invalid workflow payload", detail: err.Error()}
+	}
+
+	// Development runs use the pinned outputs of the nodes instead of executing them
+	mode, err := queue.ParseMode(options["mode"])
+	if err != nil {
+		return nil, &runError{status: http.StatusBadRequest, message: "Invalid workflow payload", detail: err.Error()}
+	}
+
+	// Resolve an optional deferred execution time (runAt / delay)
+	runAt, deferred, err := scheduler.ParseRunAt(options, time.Now())
+	if err != nil {
+		s.logger.Warn("Invalid workflow schedule",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return nil, &runError{status: http.StatusBadRequest, message: "Invalid workflow payload", detail: err.Error()}
+	}
+	if deferred && !runAt.After(time.Now()) {
+		deferred = false // Already due: enqueue right away
+	}
+
+	// Initialize workflow so trigger selection errors reach the client
+	workflowComplete, err := builder.InitWorkflow(parsedWorkflow)
+	if err != nil {
+		s.logger.Error("Workflow initialization failed",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return nil, workflowError("Failed to initialize workflow", err)
+	}
+
+	workflowComplete.ID = workflowID
+	if mode == queue.ModeProduction {
+		workflowComplete = workflowComplete.WithoutPinnedData() // Pinned outputs never reach production runs
+	}
+
+	// Debug runs can add breakpoints to the ones flagged in the graph
+	breakpoints, err := parseBreakpoints(workflowComplete, mode, options["breakpoints"])
+	if err != nil {
+		return nil, &runError{status: http.StatusBadRequest, message: "Invalid workflow payload", detail: err.Error()}
+	}
+
+	// The run starts from the requested trigger only (defaults to the manual trigger)
+	requestedTrigger, _ := options["triggerNodeId"].(string)
+	startNode, err := workflowComplete.SelectStartNode(requestedTrigger)
+	if err != nil {
+		return nil, &runError{status: http.StatusUnprocessableEntity, message: "Invalid trigger", detail: err.Error()}
+	}
+
+	// Validate the run input against the schema declared by the trigger (defaults applied)
+	rawInput, isObject := options["input"].(map[string]interface{})
+	if options["input"] != nil && !isObject {
+		return nil, &runError{status: http.StatusBadRequest, message: "Invalid run input", detail: "input must be a JSON object"}
+	}
+	input, fieldErrs, err := startNode.ValidateInput(rawInput)
+	if err != nil {
+		return nil, &runError{status: http.StatusUnprocessableEntity, message: "Invalid trigger", detail: err.Error()}
+	}
+	if len(fieldErrs) > 0 {
+		fieldErrs = fieldErrs.Prefixed("/input")
+		s.logger.Warn("Run input validation failed",
+			zap.String("request_id", requestID),
+			zap.Int("error_count", len(fieldErrs)),
+		)
+		return nil, &runError{
+			status:  http.StatusUnprocessableEntity,
+			message: "Invalid run input",
+			detail:  fieldErrs.Error(),
+			fields:  fieldErrs,
+		}
+	}
+
+	job := &queue.Job{
+		RunID:         ids.New("run"),
+		WorkflowID:    workflowComplete.ID,
+		Revision:      revision,
+		Priority:      priority,
+		Trigger:       string(nodetypes.TriggerKindOf(startNode.Type)),
+		TriggerNodeID: startNode.ID,
+		Input:         input,
+		Mode:          mode,
+		Breakpoints:   breakpoints,
+	}
+
+	// A retry reuses the recorded responses of the nodes upstream of its first node
+	if origin != nil {
+		reuse, err := retry.Plan(workflowComplete, origin.from, origin.result)
+		if err != nil {
+			return nil, &runError{status: http.StatusUnprocessableEntity, message: "Invalid retry", detail: err.Error()}
+		}
+		job.RetryOf = origin.runID
+		job.RetryFrom = origin.from
+		job.Reuse = reuse
+	}
+
+	// Record the run before answering so its ID can be polled right away
+	runStatus := runs.StatusQueued
+	if deferred {
+		runStatus = runs.StatusScheduled
+	}
+	record := runs.FromJob(job, runStatus)
+	if revision == 0 {
+		// Ad-hoc graphs are not stored elsewhere: keep them with the run so it can be retried
+		if record.Graph, err = json.Marshal(parsedWorkflow); err != nil {
+			return nil, &runError{status: http.StatusInternalServerError, message: "Failed to record workflow run", detail: err.Error()}
+		}
+	}
+	if err := runs.Create(RedisClient.GetClient(), record); err != nil {
+		s.logger.Error("Failed to record workflow run",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return nil, &runError{status: http.StatusInternalServerError, message: "Failed to record workflow run", detail: err.Error()}
+	}
+
+	data := map[string]interface{}{
+		"id":              workflowComplete.ID,
+		"run_id":          job.RunID,
+		"priority":        job.Priority.String(),
+		"mode":            job.Mode,
+		"trigger_node_id": startNode.ID,
+		"request_id":      requestID,
+		"node_count": len(parsedWorkflow.Nodes),
+		"edge_count": len(parsedWorkflow.Edges),
+		"created_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	if revision > 0 {
+		data["revision"] = revision
+	}
+	if len(workflowComplete.Warnings) > 0 {
+		data["warnings"] = workflowComplete.Warnings
+	}
+	if origin != nil {
+		data["retry_of"] = job.RetryOf
+		data["retry_from"] = job.RetryFrom
+		data["reused_node_count"] = len(job.Reuse)
+	}
+	message := "Workflow parsed and queued successfully"
+	if deferred {
+		data["run_at"] = runAt.UTC().Format(time.RFC3339)
+		message = "Workflow parsed and scheduled successfully"
+	} else {
+		runAt = time.Time{}
+	}
+
+	if err := s.dispatchWorkflow(workflowComplete, job, runAt, requestID); err != nil {
+		if finishErr := runs.Finish(RedisClient.GetClient(), job.RunID, runs.StatusError, err.Error()); finishErr != nil {
+			s.logger.Warn("Failed to record run outcome", zap.String("run_id", job.RunID), zap.Error(finishErr))
+		}
+		return nil, &runError{status: http.StatusInternalServerError, message: "Failed to queue workflow run", detail: err.Error()}
+	}
+
+	return &submittedRun{job: job, deferred: deferred, message: message, data: data}, nil
+}
+
+// dispatchWorkflow serializes the workflow into the job and pushes it on its
+// execution queue before the submission is answered, so a run reported as
+// queued (or scheduled) is always in Redis.
+// A non-zero runAt defers the job to the scheduled set instead of the execution queue.
+func (s *Server) dispatchWorkflow(workflowComplete *builder.Workflow, job *queue.Job, runAt time.Time, requestID string) error {
+	// Convert to JSON for storage
+	jsonData, err := json.MarshalIndent(workflowComplete, "", "  ")
+	if err != nil {
+		s.logger.Error("Failed to marshal workflow to JSON",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to marshal workflow: %w", err)
+	}
+
+	s.logger.Debug("Workflow JSON formatted",
+		zap.String("request_id", requestID),
+		zap.String("workflow_id", workflowComplete.ID),
+	)
+
+	job.Workflow = jsonData
+
+	// Deferred runs wait in the scheduled set until the promoter picks them up
+	if !runAt.IsZero() {
+		if _, err := scheduler.Schedule(RedisClient.GetClient(), job, runAt); err != nil {
+			s.logger.Error("Failed to schedule workflow",
+				zap.String("request_id", requestID),
+				zap.Error(err),
+			)
+			return fmt.Errorf("failed to schedule workflow: %w", err)
+		}
+
+		s.logger.Info("Workflow successfully processed and scheduled",
+			zap.String("request_id", requestID),
+			zap.String("workflow_id", workflowComplete.ID),
+			zap.String("run_id", job.RunID),
+			zap.Time("run_at", runAt),
+		)
+		return nil
+	}
+
+	// Save to Redis
+	if err := s.saveWorkflowToRedis(job, requestID); err != nil {
+		s.logger.Error("Failed to save workflow to Redis",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	s.logger.Info("Workflow successfully processed and saved",
+		zap.String("request_id", requestID),
+		zap.String("workflow_id", workflowComplete.ID),
+		zap.String("run_id", job.RunID),
+		zap.String("priority", job.Priority.String()),
+	)
+	return nil
+}
+
+// saveWorkflowToRedis pushes the job on the queue matching its priority
+func (s *Server) saveWorkflowToRedis(job *queue.Job, requestID string) error {
+	client := RedisClient.GetClient()
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	if err := runs.Enqueue(client, job); err != nil {
+		return fmt.Errorf("failed to push to Redis: %w", err)
+	}
+	return nil
+}
+
+// handleWorkflowValidation validates workflow without processing it
+func (s *Server) handleWorkflowValidation(w http.ResponseWriter, r *http.Request) {
+	// This endpoint can be used to validate workflows without processing them
+	// Useful for frontend validation or testing
+	var payload map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+
+	// Validate using parser (dry run)
+	parsedWorkflow, err := parser.ParseWorkflow(payload)
+	if err != nil {
+		s.writeRunError(w, workflowError("Workflow validation failed", err))
+		return
+	}
+
+	// Graph structure: cycles, triggers, unreachable nodes
+	workflowComplete, err := builder.InitWorkflow(parsedWorkflow)
+	if err != nil {
+		s.writeRunError(w, workflowError("Workflow validation failed", err))
+		return
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow validation passed",
+		Data:    validationReport(workflowComplete.Warnings),
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+
+func (s *Server) handleGetWorkflow(w http.ResponseWriter, r *http.Request) {
+    id := chi.URLParam(r, "id")
+    if id == "" {
+        s.writeErrorResponse(w, http.StatusBadRequest, "Missing workflow ID", "ID parameter is required")
+        return
+    }
+
+    client := RedisClient.GetClient()
+    if client == nil {
+        s.writeErrorResponse(w, http.StatusInternalServerError, "Redis client not initialized", "Failed to connect to Redis")
+        return
+    }
+
+    // Retrieve the results of the latest run from Redis
+    latest, _, err := runs.ListByWorkflow(id, 0, 1)
+    if err != nil || len(latest) == 0 {
+        s.writeErrorResponse(w, http.StatusNotFound, "Workflow not found", fmt.Sprintf("No workflow found with ID %s", id))
+        return
+    }
+    res, err := runs.Results(client, latest[0].RunID)
+    if err != nil {
+        s.writeErrorResponse(w, http.StatusNotFound, "Workflow not found", fmt.Sprintf("No results yet for the latest run of workflow %s", id))
+        return
+    }
+
+    // 🎯 Structure clean - directement les données utiles
+    response := APIResponse{
+        Status:  "success",
+        Message: "Workflow retrieved successfully",
+        Data: map[string]interface{}{
+            "id":      id,
+            "run_id":  latest[0].RunID,
+            "results": string(res),
+        },
+    }
+    
+    s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleListWorkflowRuns lists the run history of a workflow, most recent first
+func (s *Server) handleListWorkflowRuns(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	limit, err := parsePageParam(r, "limit", DefaultPageSize)
+	if err != nil || limit == 0 || limit > MaxPageSize {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid pagination", fmt.Sprintf("limit must be between 1 and %d", MaxPageSize))
+		return
+	}
+	offset, err := parsePageParam(r, "offset", 0)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid pagination", "offset must be a positive integer")
+		return
+	}
+
+	list, total, err := runs.ListByWorkflow(id, offset, limit)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list workflow runs", err.Error())
+		return
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow runs retrieved successfully",
+		Data: map[string]interface{}{
+			"id":     id,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"runs":   list,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleGetRun returns a run record and its latest execution results
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	runID := chi.URLParam(r, "runId")
+	client := RedisClient.GetClient()
+
+	run, err := runs.Get(client, runID)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "Run not found", fmt.Sprintf("No run found with ID %s", runID))
+		return
+	}
+
+	data := map[string]interface{}{
+		"run": run,
+	}
+	if res, err := runs.Results(client, runID); err == nil {
+		data["results"] = res
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Run retrieved successfully",
+		Data:    data,
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleRetryRun starts a new run of a finished run's graph from one of its nodes
+// (?from=, defaults to the node the run failed at). That node and everything
+// downstream of it are executed again; the recorded responses of the other
+// nodes are reused. Supports ?wait= like run submissions.
+func (s *Server) handleRetryRun(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	runID := chi.URLParam(r, "runId")
+
+	wait, err := parseWait(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid wait parameter", err.Error())
+		return
+	}
+
+	run, runErr := s.retryRun(r.Context(), requestID, runID, strings.TrimSpace(r.URL.Query().Get("from")))
+	if run != nil {
+		run.message = "Workflow run retry queued successfully"
+	}
+	s.writeRunSubmission(w, r, run, runErr, wait)
+}
+
+// retryRun submits a retry of a finished run from a node ("" for the node it failed at).
+// The retry runs the same graph (stored revision or ad-hoc graph), trigger, input, mode and priority.
+func (s *Server) retryRun(ctx context.Context, requestID, runID, from string) (*submittedRun, *runError) {
+	client := RedisClient.GetClient()
+
+	original, err := runs.Get(client, runID)
+	if err != nil {
+		return nil, &runError{status: http.StatusNotFound, message: "Run not found", detail: fmt.Sprintf("No run found with ID %s", runID)}
+	}
+	if !original.Status.IsFinal() {
+		return nil, &runError{status: http.StatusConflict, message: "Run not finished", detail: fmt.Sprintf("Run %s is %s", runID, original.Status)}
+	}
+
+	result, err := runs.Results(client, runID)
+	if err != nil {
+		return nil, &runError{status: http.StatusInternalServerError, message: "Failed to read run results", detail: err.Error()}
+	}
+
+	if from == "" {
+		failed, ok, err := retry.FailedNode(result)
+		if err != nil {
+			return nil, &runError{status: http.StatusInternalServerError, message: "Failed to read run results", detail: err.Error()}
+		}
+		if !ok {
+			return nil, &runError{status: http.StatusBadRequest, message: "Missing from parameter", detail: fmt.Sprintf("Run %s has no failed node, from is required", runID)}
+		}
+		from = failed
+	}
+
+	// Same graph as the original run
+	var graph *parser.Payload
+	if original.Revision > 0 {
+		def, err := definition.GetRevision(ctx, storage.GetStore(), original.WorkflowID, original.Revision)
+		if err != nil {
+			return nil, definitionError(original.WorkflowID, err)
+		}
+		graph = def.Graph()
+	} else {
+		raw, err := runs.Graph(runID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, &runError{status: http.StatusInternalServerError, message: "Failed to read run graph", detail: err.Error()}
+		}
+		if raw == nil {
+			return nil, &runError{status: http.StatusConflict, message: "Run cannot be retried", detail: fmt.Sprintf("No graph was recorded for run %s", runID)}
+		}
+		graph = &parser.Payload{}
+		if err := json.Unmarshal(raw, graph); err != nil {
+			return nil, &runError{status: http.StatusInternalServerError, message: "Failed to read run graph", detail: err.Error()}
+		}
+	}
+
+	options := map[string]interface{}{
+		"priority":      original.Priority,
+		"mode":          original.Mode,
+		"triggerNodeId": original.TriggerNodeID,
+	}
+	if original.Input != nil {
+		options["input"] = original.Input
+	}
+
+	s.logger.Info("Retrying workflow run",
+		zap.String("request_id", requestID),
+		zap.String("run_id", runID),
+		zap.String("from", from),
+	)
+	return s.submitRun(requestID, original.WorkflowID, original.Revision, graph, options, &retryOrigin{runID: runID, from: from, result: result})
+}
+
+// handleExecuteNode executes a single node on a worker and answers with its response.
+// The body is {"node": {"id", "type", "data"}, "input": {...}}: input is the mock
+// data handed to the node as its run input. The node runs through the same
+// executors as workflow runs but leaves no run record. ?wait= bounds the wait
+// (defaults to RequestTimeout).
+func (s *Server) handleExecuteNode(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+
+	var payload struct {
+		Node  *parser.RawNode        `json:"node"`
+		Input map[string]interface{} `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+
+	wait, err := parseWait(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid wait parameter", err.Error())
+		return
+	}
+	if wait == 0 {
+		wait = RequestTimeout
+	}
+
+	job, runErr := s.nodeJob(payload.Node, payload.Input)
+	if runErr != nil {
+		s.writeRunError(w, runErr)
+		return
+	}
+
+	client := RedisClient.GetClient()
+	if err := queue.Enqueue(client, job); err != nil {
+		s.logger.Error("Failed to queue node execution",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to queue node execution", err.Error())
+		return
+	}
+
+	s.logger.Info("Node execution queued",
+		zap.String("request_id", requestID),
+		zap.String("job_id", job.RunID),
+		zap.String("node_type", payload.Node.Type),
+	)
+
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + WriteTimeout))
+
+	rawResponse, err := queue.WaitResult(r.Context(), job.RunID, wait)
+	if errors.Is(err, queue.ErrResultTimeout) {
+		s.writeErrorResponse(w, http.StatusGatewayTimeout, "Node execution timed out",
+			fmt.Sprintf("No worker answered within %s", wait))
+		return
+	}
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to wait for node execution", err.Error())
+		return
+	}
+
+	var nodeStatus struct {
+		Status string `json:"status"`
+	}
+	json.Unmarshal([]byte(rawResponse), &nodeStatus)
+
+	message := "Node executed successfully"
+	if nodeStatus.Status != string(runs.StatusSuccess) {
+		message = fmt.Sprintf("Node execution finished with status %s", nodeStatus.Status)
+	}
+	s.writeJSONResponse(w, http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: message,
+		Data:    json.RawMessage(rawResponse),
+	})
+}
+
+// handleListNodeTypes returns every registered node type with its editor metadata,
+// configuration schema and handles, sorted by type.
+func (s *Server) handleListNodeTypes(w http.ResponseWriter, r *http.Request) {
+	defs := nodetypes.All()
+	s.writeJSONResponse(w, http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Node types retrieved successfully",
+		Data:    defs,
+	})
+}
+
+// handleGetNodeTypeSchema returns the JSON Schema of the data of a node type,
+// the one submitted workflows are validated against.
+func (s *Server) handleGetNodeTypeSchema(w http.ResponseWriter, r *http.Request) {
+	nodeType := chi.URLParam(r, "type")
+	def, ok := nodetypes.Lookup(nodeType)
+	if !ok {
+		s.writeErrorResponse(w, http.StatusNotFound, "Node type not found", fmt.Sprintf("unknown node type %s", nodeType))
+		return
+	}
+
+	config := def.Config
+	if config == nil {
+		config = &schema.Schema{Type: schema.Types{schema.TypeObject}}
+	}
+	s.writeJSONResponse(w, http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Node type schema retrieved successfully",
+		Data:    config,
+	})
+}
+
+// nodeJob validates a node submitted for isolated execution and builds its job.
+// Trigger nodes get their input checked against their input schema, like runs.
+func (s *Server) nodeJob(rawNode *parser.RawNode, input map[string]interface{}) (*queue.Job, *runError) {
+	if rawNode == nil {
+		return nil, &runError{status: http.StatusBadRequest, message: "Invalid node", detail: "node is required"}
+	}
+	if rawNode.Type == "" {
+		return nil, &runError{status: http.StatusBadRequest, message: "Invalid node", detail: "node type is required"}
+	}
+	def, ok := nodetypes.Lookup(rawNode.Type)
+	if !ok {
+		return nil, &runError{status: http.StatusUnprocessableEntity, message: "Invalid node", detail: fmt.Sprintf("unknown node type %s", rawNode.Type)}
+	}
+	if fieldErrs := def.ValidateConfig(rawNode.Data); len(fieldErrs) > 0 {
+		fieldErrs = fieldErrs.Prefixed("/node/data")
+		return nil, &runError{status: http.StatusUnprocessableEntity, message: "Invalid node configuration", detail: fieldErrs.Error(), fields: fieldErrs}
+	}
+
+	node := &builder.Node{
+		ID:          rawNode.ID,
+		Type:        rawNode.Type,
+		Data:        rawNode.Data,
+		NextIDs:     []string{},
+		PreviousIDs: []string{},
+	}
+	if node.ID == "" {
+		node.ID = "node"
+	}
+	if node.Data == nil {
+		node.Data = map[string]interface{}{}
+	}
+
+	if nodetypes.IsTrigger(node.Type) {
+		validated, fieldErrs, err := node.ValidateInput(input)
+		if err != nil {
+			return nil, &runError{status: http.StatusUnprocessableEntity, message: "Invalid node", detail: err.Error()}
+		}
+		if len(fieldErrs) > 0 {
+			fieldErrs = fieldErrs.Prefixed("/input")
+			return nil, &runError{status: http.StatusUnprocessableEntity, message: "Invalid node input", detail: fieldErrs.Error(), fields: fieldErrs}
+		}
+		input = validated
+	}
+
+	data, err := json.Marshal(node)
+	if err != nil {
+		return nil, &runError{status: http.StatusInternalServerError, message: "Failed to encode node", detail: err.Error()}
+	}
+
+	// Interactive tests jump ahead of regular runs
+	return &queue.Job{
+		Kind:     queue.KindNode,
+		RunID:    ids.New("exec"),
+		Priority: queue.PriorityHigh,
+		Node:     data,
+		Input:    input,
+	}, nil
+}
+
+// handleRunEvents streams the progress of a run as Server-Sent Events.
+// Recorded events are replayed first, then new ones are pushed as the worker
+// records them. A client reconnecting with Last-Event-ID (or ?lastEventId=)
+// only receives the events it missed. The stream ends with run.finished, or
+// with an "end" event when the run finished without recording one.
+func (s *Server) handleRunEvents(w http.ResponseWriter, r *http.Request) {
+	runID := chi.URLParam(r, "runId")
+	client := RedisClient.GetClient()
+	requestID := middleware.GetReqID(r.Context())
+
+	run, err := runs.Get(client, runID)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "Run not found", fmt.Sprintf("No run found with ID %s", runID))
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+
+	// Validates the resume point and tells whether anything is left to send
+	pending, err := runlog.Events(client, runID, lastID, 1)
+	if err != nil {
+		if lastID != "" {
+			s.writeErrorResponse(w, http.StatusBadRequest, "Invalid Last-Event-ID", err.Error())
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to read run events", err.Error())
+		return
+	}
+
+	// Nothing left to send: 204 tells EventSource clients not to reconnect
+	if lastID != "" && len(pending) == 0 && run.Status.IsFinal() {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering
+	w.WriteHeader(http.StatusOK)
+
+	stream := http.NewResponseController(w)
+	write := func(format string, args ...interface{}) bool {
+		// The server write timeout does not apply to a live stream
+		stream.SetWriteDeadline(time.Now().Add(WriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return stream.Flush() == nil
+	}
+
+	s.logger.Debug("Run event stream opened",
+		zap.String("request_id", requestID),
+		zap.String("run_id", runID),
+		zap.String("last_event_id", lastID),
+	)
+
+	ended, err := runs.Follow(r.Context(), client, runID, lastID, StreamHeartbeat,
+		func(event runlog.Event) bool {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return true
+			}
+			return write("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		},
+		func() bool {
+			return write(": heartbeat\n\n")
+		},
+	)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		s.logger.Warn("Run event stream failed",
+			zap.String("request_id", requestID),
+			zap.String("run_id", runID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	// The run finished without a run.finished event
+	if ended != nil {
+		data, _ := json.Marshal(map[string]interface{}{"runId": ended.RunID, "status": ended.Status})
+		write("event: end\ndata: %s\n\n", data)
+	}
+}
+
+// handleSession serves an interactive editor session over WebSocket.
+// The protocol is documented in package session.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already answered the client
+		s.logger.Warn("Session upgrade failed",
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	es := &editorSession{
+		server:    s,
+		conn:      session.NewConn(ws),
+		requestID: requestID,
+		ctx:       ctx,
+		follows:   make(map[string]context.CancelFunc),
+	}
+	defer func() {
+		cancel()
+		es.conn.Close()
+		s.logger.Info("Editor session closed", zap.String("request_id", requestID))
+	}()
+
+	s.logger.Info("Editor session opened",
+		zap.String("request_id", requestID),
+		zap.String("client_ip", r.RemoteAddr),
+	)
+
+	es.conn.Send(session.TypeWelcome, "", "", session.WelcomePayload{
+		Version:  session.Version,
+		Versions: session.SupportedVersions,
+	})
+
+	// Keep-alive pings; a missing pong ends the read loop
+	go func() {
+		ticker := time.NewTicker(session.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := es.conn.Ping(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		data, err := es.conn.Read()
+		if err != nil {
+			return
+		}
+		es.handle(data)
+	}
+}
+
+// editorSession is the state of an open editor session
+type editorSession struct {
+	server    *Server
+	conn      *session.Conn
+	requestID string
+	ctx       context.Context // Cancelled when the connection closes
+
+	mu      sync.Mutex
+	follows map[string]context.CancelFunc // Followed runs
+}
+
+// handle dispatches a client message
+func (es *editorSession) handle(data []byte) {
+	msg, msgErr := session.Decode(data)
+	if msgErr != nil {
+		id, runID := "", ""
+		if msg != nil {
+			id, runID = msg.ID, msg.RunID
+		}
+		es.conn.SendError(id, runID, msgErr)
+		return
+	}
+
+	switch msg.Type {
+	case session.TypePing:
+		es.conn.Send(session.TypePong, msg.ID, "", nil)
+
+	case session.TypeSubmit:
+		es.submit(msg)
+
+	case session.TypeSubscribe:
+		var payload session.SubscribePayload
+		if len(msg.Payload) > 0 {
+			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+				es.conn.SendError(msg.ID, msg.RunID, &session.ErrorPayload{Code: session.CodeInvalidRequest, Message: "Invalid payload", Detail: err.Error()})
+				return
+			}
+		}
+		if _, err := runs.Get(RedisClient.GetClient(), msg.RunID); err != nil {
+			es.conn.SendError(msg.ID, msg.RunID, &session.ErrorPayload{Code: session.CodeNotFound, Message: "Run not found"})
+			return
+		}
+		es.conn.Send(session.TypeAck, msg.ID, msg.RunID, nil)
+		es.follow(msg.RunID, payload.LastEventID)
+
+	case session.TypeUnsubscribe:
+		es.mu.Lock()
+		if stop, ok := es.follows[msg.RunID]; ok {
+			stop()
+			delete(es.follows, msg.RunID)
+		}
+		es.mu.Unlock()
+		es.conn.Send(session.TypeAck, msg.ID, msg.RunID, nil)
+
+	case session.TypeControl:
+		var payload session.ControlPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			es.conn.SendError(msg.ID, msg.RunID, &session.ErrorPayload{Code: session.CodeInvalidRequest, Message: "Invalid payload", Detail: "expected {\"action\": ...}"})
+			return
+		}
+		action, err := control.ParseAction(payload.Action)
+		if err != nil {
+			es.conn.SendError(msg.ID, msg.RunID, &session.ErrorPayload{Code: session.CodeInvalidRequest, Message: "Invalid action", Detail: err.Error()})
+			return
+		}
+		run, runErr := es.server.controlRun(msg.RunID, action)
+		if runErr != nil {
+			es.conn.SendError(msg.ID, msg.RunID, sessionError(runErr))
+			return
+		}
+		es.conn.Send(session.TypeAck, msg.ID, msg.RunID, run)
+	}
+}
+
+// submit starts an ad-hoc or stored workflow run and follows it unless told otherwise
+func (es *editorSession) submit(msg *session.Message) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload == nil {
+		es.conn.SendError(msg.ID, "", &session.ErrorPayload{Code: session.CodeInvalidRequest, Message: "Invalid payload", Detail: "expected a workflow submission object"})
+		return
+	}
+
+	subscribe := true
+	if value, ok := payload["subscribe"].(bool); ok {
+		subscribe = value
+	}
+	delete(payload, "subscribe")
+
+	var run *submittedRun
+	var runErr *runError
+	if workflowID, ok := payload["workflowId"].(string); ok && workflowID != "" {
+		delete(payload, "workflowId")
+		run, runErr = es.server.submitDefinitionRun(es.ctx, es.requestID, workflowID, payload)
+	} else {
+		parsedWorkflow, parseErr := es.server.parseSubmission(es.requestID, payload)
+		if parseErr != nil {
+			runErr = parseErr
+		} else {
+			run, runErr = es.server.submitRun(es.requestID, payload["id"].(string), 0, parsedWorkflow, payload, nil)
+		}
+	}
+	if runErr != nil {
+		es.conn.SendError(msg.ID, "", sessionError(runErr))
+		return
+	}
+
+	es.conn.Send(session.TypeAck, msg.ID, run.job.RunID, run.data)
+	if subscribe {
+		es.follow(run.job.RunID, "")
+	}
+}
+
+// follow forwards the events of a run until it ends or is unsubscribed
+func (es *editorSession) follow(runID, lastEventID string) {
+	es.mu.Lock()
+	if _, following := es.follows[runID]; following {
+		es.mu.Unlock()
+		return
+	}
+	ctx, stop := context.WithCancel(es.ctx)
+	es.follows[runID] = stop
+	es.mu.Unlock()
+
+	go func() {
+		defer func() {
+			es.mu.Lock()
+			delete(es.follows, runID)
+			es.mu.Unlock()
+			stop()
+		}()
+
+		status := ""
+		ended, err := runs.Follow(ctx, RedisClient.GetClient(), runID, lastEventID, StreamHeartbeat,
+			func(event runlog.Event) bool {
+				if ctx.Err() != nil {
+					return false
+				}
+				if event.Type == runlog.RunFinished {
+					var header struct {
+						Status string `json:"status"`
+					}
+					json.Unmarshal(event.Data, &header)
+					status = header.Status
+				}
+				return es.conn.Send(session.TypeEvent, "", runID, event) == nil
+			},
+			func() bool {
+				return ctx.Err() == nil
+			},
+		)
+		if ctx.Err() != nil {
+			return // Unsubscribed or session closed
+		}
+		if err != nil {
+			es.server.logger.Warn("Failed to follow run",
+				zap.String("request_id", es.requestID),
+				zap.String("run_id", runID),
+				zap.Error(err),
+			)
+			es.conn.SendError("", runID, &session.ErrorPayload{Code: session.CodeInternal, Message: "Failed to follow run", Detail: err.Error()})
+			return
+		}
+
+		if ended != nil {
+			status = string(ended.Status)
+		}
+		if status != "" {
+			es.conn.Send(session.TypeEnd, "", runID, session.EndPayload{RunID: runID, Status: status})
+		}
+	}()
+}
+
+// sessionError converts a REST error to a session error
+func sessionError(runErr *runError) *session.ErrorPayload {
+	code := session.CodeInternal
+	switch runErr.status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		code = session.CodeInvalidRequest
+	case http.StatusNotFound:
+		code = session.CodeNotFound
+	case http.StatusConflict:
+		code = session.CodeConflict
+	}
+	return &session.ErrorPayload{Code: code, Message: runErr.message, Detail: runErr.detail, Errors: runErr.fields, Issues: runErr.issues}
+}
+
+// handleDeliverRunEvent resumes a run suspended at an approval or wait-for-event
+// node. The body carries the correlation key the node waits for and the event
+// payload; approvals expect {"approved": bool, "by": string, "comment": string}.
+func (s *Server) handleDeliverRunEvent(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	runID := chi.URLParam(r, "runId")
+	client := RedisClient.GetClient()
+
+	var body struct {
+		CorrelationKey string          `json:"correlationKey"`
+		Payload        json.RawMessage `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+
+	if _, err := runs.Get(client, runID); err != nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "Run not found", fmt.Sprintf("No run found with ID %s", runID))
+		return
+	}
+
+	wait, err := waits.Deliver(client, runID, body.CorrelationKey, body.Payload)
+	switch {
+	case errors.Is(err, waits.ErrNotWaiting):
+		s.writeErrorResponse(w, http.StatusConflict, "Run is not waiting", fmt.Sprintf("Run %s is not waiting for an event", runID))
+		return
+	case errors.Is(err, waits.ErrKeyMismatch):
+		s.writeErrorResponse(w, http.StatusConflict, "Correlation key mismatch", err.Error())
+		return
+	case err != nil:
+		s.logger.Error("Failed to resume run",
+			zap.String("request_id", requestID),
+			zap.String("run_id", runID),
+			zap.Error(err),
+		)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to resume run", err.Error())
+		return
+	}
+
+	s.logger.Info("Run event delivered",
+		zap.String("request_id", requestID),
+		zap.String("run_id", runID),
+		zap.String("node_id", wait.NodeID),
+	)
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Event delivered, run resumed",
+		Data: map[string]interface{}{
+			"runId":    runID,
+			"nodeId":   wait.NodeID,
+			"nodeType": wait.NodeType,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusAccepted, response)
+}
+
+// handleRunCommand returns the handler sending a command to a run in progress.
+// The pending node of a paused run and its inputs are reported by GET /runs/{runId}.
+func (s *Server) handleRunCommand(action control.Action) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runID := chi.URLParam(r, "runId")
+
+		run, runErr := s.controlRun(runID, action)
+		if runErr != nil {
+			s.writeRunError(w, runErr)
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, APIResponse{
+			Status:  "success",
+			Message: "Run command sent",
+			Data: map[string]interface{}{
+				"run":    run,
+				"action": action,
+			},
+		})
+	}
+}
+
+// controlRun sends an operator command to a run that is not finished yet.
+// Scheduled, waiting and paused runs are cancelled right away and paused runs are
+// pushed again on resume and step; other commands reach the worker between nodes.
+func (s *Server) controlRun(runID string, action control.Action) (*runs.Run, *runError) {
+	client := RedisClient.GetClient()
+
+	run, err := runs.Get(client, runID)
+	if err != nil {
+		return nil, &runError{status: http.StatusNotFound, message: "Run not found", detail: fmt.Sprintf("No run found with ID %s", runID)}
+	}
+	if run.Status.IsFinal() {
+		return nil, &runError{status: http.StatusConflict, message: "Run already finished", detail: fmt.Sprintf("Run %s is %s", runID, run.Status)}
+	}
+
+	// A suspended run is not held by any worker: its wait is dropped right away
+	suspended := run.Status == runs.StatusWaiting || run.Status == runs.StatusPaused
+	if action == control.ActionCancel && suspended {
+		if cancelled, err := waits.Cancel(client, runID); err == nil && cancelled {
+			if updated, err := runs.Get(client, runID); err == nil {
+				run = updated
+			}
+			return run, nil
+		}
+	}
+
+	if action == control.ActionCancel && run.Status == runs.StatusScheduled {
+		if cancelled, err := scheduler.Cancel(client, runID); err == nil && cancelled {
+			if updated, err := runs.Get(client, runID); err == nil {
+				run = updated
+			}
+			return run, nil
+		}
+	}
+
+	// A run waiting for an event has no next node to step into
+	if action == control.ActionStep && run.Status == runs.StatusWaiting {
+		return nil, &runError{status: http.StatusConflict, message: "Run is waiting for an event", detail: fmt.Sprintf("Run %s cannot step until its wait is resolved", runID)}
+	}
+
+	// A paused run suspended off its worker is pushed again to execute one node
+	if action == control.ActionStep && run.Status == runs.StatusPaused {
+		if err := control.StepSuspended(client, runID); err != nil {
+			return nil, &runError{status: http.StatusInternalServerError, message: "Failed to send run command", detail: err.Error()}
+		}
+		if _, err := waits.Resume(client, runID); err != nil {
+			if errors.Is(err, waits.ErrNotPaused) {
+				return nil, &runError{status: http.StatusConflict, message: "Run is not paused", detail: fmt.Sprintf("Run %s is no longer paused", runID)}
+			}
+			return nil, &runError{status: http.StatusInternalServerError, message: "Failed to step run", detail: err.Error()}
+		}
+		if updated, err := runs.Get(client, runID); err == nil {
+			run = updated
+		}
+		s.logger.Info("Run command sent", zap.String("run_id", runID), zap.String("action", string(action)))
+		return run, nil
+	}
+
+	if err := control.Send(client, runID, action); err != nil {
+		return nil, &runError{status: http.StatusInternalServerError, message: "Failed to send run command", detail: err.Error()}
+	}
+
+	// The pause command is cleared above so the resumed run does not pause again
+	if action == control.ActionResume && run.Status == runs.StatusPaused {
+		if _, err := waits.Resume(client, runID); err != nil && !errors.Is(err, waits.ErrNotPaused) {
+			return nil, &runError{status: http.StatusInternalServerError, message: "Failed to resume run", detail: err.Error()}
+		}
+		if updated, err := runs.Get(client, runID); err == nil {
+			run = updated
+		}
+	}
+
+	s.logger.Info("Run command sent",
+		zap.String("run_id", runID),
+		zap.String("action", string(action)),
+	)
+	return run, nil
+}
+
+// handleListQueues reports the length and pause state of every execution queue
+func (s *Server) handleListQueues(w http.ResponseWriter, r *http.Request) {
+	states, allPaused, err := queue.States(RedisClient.GetClient())
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to read queues", err.Error())
+		return
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Queues retrieved successfully",
+		Data: map[string]interface{}{
+			"queues":    states,
+			"allPaused": allPaused,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleQueueCommand returns the handler pausing or resuming a queue ("all" for every queue).
+// Jobs already executing are not affected; queued jobs wait until the queue is resumed.
+func (s *Server) handleQueueCommand(pause bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := middleware.GetReqID(r.Context())
+		client := RedisClient.GetClient()
+
+		name, err := queue.ParseQueueName(chi.URLParam(r, "name"))
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusBadRequest, "Invalid queue", err.Error())
+			return
+		}
+
+		message := "Queue resumed"
+		if pause {
+			message = "Queue paused"
+			err = queue.Pause(client, name)
+		} else {
+			err = queue.Resume(client, name)
+		}
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update queue", err.Error())
+			return
+		}
+
+		s.logger.Info(message,
+			zap.String("request_id", requestID),
+			zap.String("queue", name),
+		)
+
+		states, allPaused, err := queue.States(client)
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to read queues", err.Error())
+			return
+		}
+
+		s.writeJSONResponse(w, http.StatusOK, APIResponse{
+			Status:  "success",
+			Message: message,
+			Data: map[string]interface{}{
+				"queue":     name,
+				"queues":    states,
+				"allPaused": allPaused,
+			},
+		})
+	}
+}
+
+// parsePageParam reads a non-negative integer query parameter
+func parsePageParam(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, raw)
+	}
+	return value, nil
+}
+
+// handleCreateDefinition stores a new workflow definition
+func (s *Server) handleCreateDefinition(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+
+	var payload map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+	if id, _ := payload["id"].(string); id == "" {
+		payload["id"] = ids.New("wf")
+	}
+
+	def, ok := s.definitionFromPayload(w, payload)
+	if !ok {
+		return
+	}
+
+	if err := definition.Create(r.Context(), storage.GetStore(), def); err != nil {
+		if errors.Is(err, definition.ErrExists) {
+			s.writeErrorResponse(w, http.StatusConflict, "Workflow already exists", fmt.Sprintf("A workflow with ID %s already exists", def.ID))
+			return
+		}
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to save workflow", err.Error())
+		return
+	}
+
+	s.logger.Info("Workflow definition created",
+		zap.String("request_id", requestID),
+		zap.String("workflow_id", def.ID),
+	)
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow saved successfully",
+		Data:    def,
+	}
+
+	s.writeJSONResponse(w, http.StatusCreated, response)
+}
+
+// handleListDefinitions lists stored workflow definitions, most recent first
+func (s *Server) handleListDefinitions(w http.ResponseWriter, r *http.Request) {
+	limit, err := parsePageParam(r, "limit", DefaultPageSize)
+	if err != nil || limit == 0 || limit > MaxPageSize {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid pagination", fmt.Sprintf("limit must be between 1 and %d", MaxPageSize))
+		return
+	}
+	offset, err := parsePageParam(r, "offset", 0)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid pagination", "offset must be a positive integer")
+		return
+	}
+
+	summaries, total, err := definition.List(r.Context(), storage.GetStore(), offset, limit)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list workflows", err.Error())
+		return
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflows retrieved successfully",
+		Data: map[string]interface{}{
+			"total":     total,
+			"limit":     limit,
+			"offset":    offset,
+			"workflows": summaries,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleGetDefinition returns a stored workflow definition
+func (s *Server) handleGetDefinition(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	def, err := definition.Get(r.Context(), storage.GetStore(), id)
+	if err != nil {
+		s.writeDefinitionError(w, id, err)
+		return
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow retrieved successfully",
+		Data:    def,
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleUpdateDefinition replaces the graph of a stored workflow definition
+func (s *Server) handleUpdateDefinition(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var payload map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+	if bodyID, _ := payload["id"].(string); bodyID != "" && bodyID != id {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid workflow payload", fmt.Sprintf("payload id %s does not match URL id %s", bodyID, id))
+		return
+	}
+	payload["id"] = id
+
+	def, ok := s.definitionFromPayload(w, payload)
+	if !ok {
+		return
+	}
+
+	if err := definition.Update(r.Context(), storage.GetStore(), def); err != nil {
+		s.writeDefinitionError(w, id, err)
+		return
+	}
+
+	s.logger.Info("Workflow definition updated",
+		zap.String("request_id", requestID),
+		zap.String("workflow_id", def.ID),
+	)
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow updated successfully",
+		Data:    def,
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleDeleteDefinition removes a stored workflow definition
+func (s *Server) handleDeleteDefinition(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	deleted, err := definition.Delete(r.Context(), storage.GetStore(), id)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete workflow", err.Error())
+		return
+	}
+	if !deleted {
+		s.writeDefinitionError(w, id, definition.ErrNotFound)
+		return
+	}
+
+	s.logger.Info("Workflow definition deleted", zap.String("workflow_id", id))
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow deleted successfully",
+		Data: map[string]interface{}{
+			"id": id,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleRunDefinition queues a run of a stored workflow definition.
+// The optional body carries the run options: revision (defaults to the latest),
+// priority, runAt/delay and triggerNodeId.
+func (s *Server) handleRunDefinition(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	id := chi.URLParam(r, "id")
+
+	options := make(map[string]interface{})
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil && !errors.Is(err, io.EOF) {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+
+	wait, err := parseWait(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid wait parameter", err.Error())
+		return
+	}
+
+	run, runErr := s.submitDefinitionRun(r.Context(), requestID, id, options)
+	s.writeRunSubmission(w, r, run, runErr, wait)
+}
+
+// submitDefinitionRun submits a run of a stored workflow.
+// Options are the run options of submitRun plus an optional revision to run.
+func (s *Server) submitDefinitionRun(ctx context.Context, requestID, id string, options map[string]interface{}) (*submittedRun, *runError) {
+	store := storage.GetStore()
+	def, err := definition.Get(ctx, store, id)
+	if err != nil {
+		return nil, definitionError(id, err)
+	}
+
+	// The job embeds the graph of the selected revision, so later edits never affect it
+	if rawRevision, ok := options["revision"]; ok && rawRevision != nil {
+		revision, err := definition.ParseRevision(rawRevision)
+		if err != nil {
+			return nil, &runError{status: http.StatusBadRequest, message: "Invalid run options", detail: err.Error()}
+		}
+		if def, err = definition.GetRevision(ctx, store, id, revision); err != nil {
+			return nil, definitionError(id, err)
+		}
+	}
+
+	// Runs use the stored priority unless the request overrides it
+	if _, ok := options["priority"]; !ok {
+		options["priority"] = def.Priority.String()
+	}
+
+	return s.submitRun(requestID, def.ID, def.Revision, def.Graph(), options, nil)
+}
+
+// handleRollbackDefinition restores a previous revision as the new latest revision
+func (s *Server) handleRollbackDefinition(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var payload map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+
+	revision, err := definition.ParseRevision(payload["revision"])
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid rollback request", err.Error())
+		return
+	}
+
+	def, err := definition.Rollback(r.Context(), storage.GetStore(), id, revision)
+	if err != nil {
+		s.writeDefinitionError(w, id, err)
+		return
+	}
+
+	s.logger.Info("Workflow definition rolled back",
+		zap.String("request_id", requestID),
+		zap.String("workflow_id", id),
+		zap.Int("restored_from", revision),
+		zap.Int("revision", def.Revision),
+	)
+
+	response := APIResponse{
+		Status:  "success",
+		Message: fmt.Sprintf("Workflow rolled back to revision %d", revision),
+		Data:    def,
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleListRevisions lists the revisions of a stored workflow, most recent first
+func (s *Server) handleListRevisions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	revisions, err := definition.ListRevisions(r.Context(), storage.GetStore(), id)
+	if err != nil {
+		s.writeDefinitionError(w, id, err)
+		return
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow revisions retrieved successfully",
+		Data: map[string]interface{}{
+			"id":        id,
+			"count":     len(revisions),
+			"revisions": revisions,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleGetRevision returns the graph of a workflow at a given revision
+func (s *Server) handleGetRevision(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	revision, err := definition.ParseRevision(chi.URLParam(r, "revision"))
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid revision", err.Error())
+		return
+	}
+
+	def, err := definition.GetRevision(r.Context(), storage.GetStore(), id, revision)
+	if err != nil {
+		s.writeDefinitionError(w, id, err)
+		return
+	}
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow revision retrieved successfully",
+		Data:    def,
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// definitionFromPayload validates a workflow definition payload.
+// Writes the error response and returns false when the payload is invalid.
+func (s *Server) definitionFromPayload(w http.ResponseWriter, payload map[string]interface{}) (*definition.Definition, bool) {
+	if err := s.validateWorkflowPayload(payload); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid workflow payload", err.Error())
+		return nil, false
+	}
+
+	priority, err := queue.ParsePriority(payload["priority"])
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid workflow payload", err.Error())
+		return nil, false
+	}
+
+	parsedWorkflow, err := parser.ParseWorkflow(payload)
+	if err != nil {
+		s.writeRunError(w, workflowError("Failed to parse workflow", err))
+		return nil, false
+	}
+
+	// Only runnable graphs are stored
+	if _, err := builder.InitWorkflow(parsedWorkflow); err != nil {
+		s.writeRunError(w, workflowError("Failed to initialize workflow", err))
+		return nil, false
+	}
+
+	name, _ := payload["name"].(string)
+	description, _ := payload["description"].(string)
+
+	return &definition.Definition{
+		ID:          payload["id"].(string),
+		Name:        strings.TrimSpace(name),
+		Description: description,
+		Priority:    priority,
+		Nodes:       parsedWorkflow.Nodes,
+		Edges:       parsedWorkflow.Edges,
+	}, true
+}
+
+// writeDefinitionError maps definition store errors to HTTP responses
+func (s *Server) writeDefinitionError(w http.ResponseWriter, id string, err error) {
+	runErr := definitionError(id, err)
+	s.writeErrorResponse(w, runErr.status, runErr.message, runErr.detail)
+}
 
-	s.router = r
+// definitionError maps a definition store error to the error reported to the client
+func definitionError(id string, err error) *runError {
+	if errors.Is(err, definition.ErrNotFound) {
+		return &runError{status: http.StatusNotFound, message: "Workflow not found", detail: fmt.Sprintf("No workflow found with ID %s", id)}
+	}
+	if errors.Is(err, definition.ErrRevisionNotFound) {
+		return &runError{status: http.StatusNotFound, message: "Workflow revision not found", detail: fmt.Sprintf("Workflow %s has no such revision", id)}
+	}
+	return &runError{status: http.StatusInternalServerError, message: "Failed to access workflow", detail: err.Error()}
 }
 
-// handleRoot provides a welcome message and basic server info
-func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+// handleListScheduled lists jobs waiting for their scheduled time
+func (s *Server) handleListScheduled(w http.ResponseWriter, r *http.Request) {
+	jobs, err := scheduler.List(RedisClient.GetClient())
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list scheduled jobs", err.Error())
+		return
+	}
+
 	response := APIResponse{
 		Status:  "success",
-		Message: "Worker Manager API Server",
+		Message: "Scheduled jobs retrieved successfully",
 		Data: map[string]interface{}{
-			"version":   "1.0.5",
-			"endpoints": []string{"/api/v1/workflow", "/health", "/version"},
-			"docs":      "Visit /api/v1/workflow for workflow processing",
-			"contact":   "For support, contact : https://github.com/MaXianbibi",
+			"count": len(jobs),
+			"jobs":  jobs,
 		},
 	}
 
 	s.writeJSONResponse(w, http.StatusOK, response)
 }
 
-// handleVersion returns server version information
-func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+// handleGetScheduled returns a single pending scheduled job
+func (s *Server) handleGetScheduled(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	job, err := scheduler.Get(RedisClient.GetClient(), id)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "Scheduled job not found", fmt.Sprintf("No pending scheduled job with ID %s", id))
+		return
+	}
+
 	response := APIResponse{
-		Status: "success",
-		Data: map[string]string{
-			"version":    "1.0.0",
-			"build_time": time.Now().Format(time.RFC3339),
-			"go_version": runtime.Version(),
+		Status:  "success",
+		Message: "Scheduled job retrieved successfully",
+		Data:    job,
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleCancelScheduled cancels a scheduled job before it fires
+func (s *Server) handleCancelScheduled(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	cancelled, err := scheduler.Cancel(RedisClient.GetClient(), id)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to cancel scheduled job", err.Error())
+		return
+	}
+	if !cancelled {
+		s.writeErrorResponse(w, http.StatusNotFound, "Scheduled job not found", fmt.Sprintf("No pending scheduled job with ID %s (already fired or cancelled)", id))
+		return
+	}
+
+	s.logger.Info("Scheduled job cancelled", zap.String("run_id", id))
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Scheduled job cancelled successfully",
+		Data: map[string]interface{}{
+			"id": id,
 		},
 	}
 
 	s.writeJSONResponse(w, http.StatusOK, response)
 }
 
-// handleWorkflowSubmission processes workflow parsing requests
-func (s *Server) handleWorkflowSubmission(w http.ResponseWriter, r *http.Request) {
-	// Extract request ID for correlation
+// handleActivateWorkflow registers a workflow so its automatic triggers start runs
+func (s *Server) handleActivateWorkflow(w http.ResponseWriter, r *http.Request) {
 	requestID := middleware.GetReqID(r.Context())
 
-	s.logger.Info("Processing workflow submission",
-		zap.String("request_id", requestID),
-		zap.String("client_ip", r.RemoteAddr),
-	)
-
-	// Parse and validate request payload
 	var payload map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		s.logger.Warn("Invalid JSON payload received",
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
 		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
 		return
 	}
 
-	// Basic payload validation
 	if err := s.validateWorkflowPayload(payload); err != nil {
-		s.logger.Warn("Workflow payload validation failed",
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
 		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid workflow payload", err.Error())
 		return
 	}
 
-	// Parse workflow using the parser package
+	priority, err := queue.ParsePriority(payload["priority"])
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid workflow payload", err.Error())
+		return
+	}
+
 	parsedWorkflow, err := parser.ParseWorkflow(payload)
 	if err != nil {
-		s.logger.Error("Workflow parsing failed",
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
-		s.writeErrorResponse(w, http.StatusUnprocessableEntity, "Failed to parse workflow", err.Error())
+		s.writeRunError(w, workflowError("Failed to parse workflow", err))
 		return
 	}
-	
-	// Log successful parsing with metrics
-	s.logger.Info("Workflow parsed successfully",
+
+	workflowComplete, err := builder.InitWorkflow(parsedWorkflow)
+	if err != nil {
+		s.writeRunError(w, workflowError("Failed to initialize workflow", err))
+		return
+	}
+	workflowComplete.ID = payload["id"].(string)
+
+	act, err := activation.New(workflowComplete, priority)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusUnprocessableEntity, "Workflow cannot be activated", err.Error())
+		return
+	}
+
+	// Reject misconfigured triggers before they reach the scheduler
+	for _, trigger := range act.TriggersOfType(scheduler.CronTriggerNodeType) {
+		if _, err := scheduler.ParseCronSpec(trigger.Data, s.cron.DefaultPolicy()); err != nil {
+			s.writeErrorResponse(w, http.StatusUnprocessableEntity, "Invalid cron trigger",
+				fmt.Sprintf("node %s: %v", trigger.NodeID, err))
+			return
+		}
+	}
+	for _, trigger := range act.TriggersOfType(event.TriggerNodeType) {
+		if _, err := event.ParseName(trigger.Data); err != nil {
+			s.writeErrorResponse(w, http.StatusUnprocessableEntity, "Invalid event trigger",
+				fmt.Sprintf("node %s: %v", trigger.NodeID, err))
+			return
+		}
+	}
+
+	client := RedisClient.GetClient()
+
+	// Release the webhook paths of a previous activation before claiming the new ones
+	if previous, err := activation.Get(client, act.WorkflowID); err == nil {
+		if err := webhook.UnregisterActivation(client, previous); err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to activate workflow", err.Error())
+			return
+		}
+	}
+	if err := webhook.RegisterActivation(client, act); err != nil {
+		var conflict *webhook.ConflictError
+		if errors.As(err, &conflict) {
+			s.writeErrorResponse(w, http.StatusConflict, "Webhook path already in use", err.Error())
+		} else {
+			s.writeErrorResponse(w, http.StatusUnprocessableEntity, "Invalid webhook trigger", err.Error())
+		}
+		return
+	}
+
+	if err := scheduler.ResetCronState(client, act); err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to activate workflow", err.Error())
+		return
+	}
+	if err := activation.Save(client, act); err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to activate workflow", err.Error())
+		return
+	}
+
+	s.logger.Info("Workflow activated",
 		zap.String("request_id", requestID),
-		zap.Int("node_count", len(parsedWorkflow.Nodes)),
-		zap.Int("edge_count", len(parsedWorkflow.Edges)),
+		zap.String("workflow_id", act.WorkflowID),
+		zap.Int("trigger_count", len(act.Triggers)),
 	)
 
-	// Return success response immediately to client
 	response := APIResponse{
 		Status:  "success",
-		Message: "Workflow parsed and queued successfully",
+		Message: "Workflow activated successfully",
 		Data: map[string]interface{}{
-			"id":         payload["id"].(string),
-			"request_id": requestID,
-			"node_count": len(parsedWorkflow.Nodes),
-			"edge_count": len(parsedWorkflow.Edges),
-			"created_at": time.Now().UTC().Format(time.RFC3339),
+			"id":           act.WorkflowID,
+			"triggers":     act.Triggers,
+			"priority":     act.Priority.String(),
+			"activated_at": time.UnixMilli(act.ActivatedAt).UTC().Format(time.RFC3339),
 		},
 	}
 
 	s.writeJSONResponse(w, http.StatusCreated, response)
-
-	// Process workflow in background (non-blocking)
-	go s.processWorkflowAsync(parsedWorkflow, payload, requestID)
 }
 
-// processWorkflowAsync handles the workflow initialization and storage asynchronously
-func (s *Server) processWorkflowAsync(parsedWorkflow *parser.Payload, payload map[string]interface{}, requestID string) {
-	// Initialize workflow
-	workflowComplete, err := builder.InitWorkflow(parsedWorkflow)
+// handleListActivations lists the activated workflows and their triggers
+func (s *Server) handleListActivations(w http.ResponseWriter, r *http.Request) {
+	activations, err := activation.List(RedisClient.GetClient())
 	if err != nil {
-		s.logger.Error("Workflow initialization failed",
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list activations", err.Error())
 		return
 	}
 
-	// Set workflow ID from payload
-	workflowComplete.ID = payload["id"].(string)
+	items := make([]map[string]interface{}, 0, len(activations))
+	for _, act := range activations {
+		items = append(items, map[string]interface{}{
+			"id":           act.WorkflowID,
+			"triggers":     act.Triggers,
+			"priority":     act.Priority.String(),
+			"activated_at": time.UnixMilli(act.ActivatedAt).UTC().Format(time.RFC3339),
+		})
+	}
 
-	// Convert to JSON for storage
-	jsonData, err := json.MarshalIndent(workflowComplete, "", "  ")
+	response := APIResponse{
+		Status:  "success",
+		Message: "Activations retrieved successfully",
+		Data: map[string]interface{}{
+			"count":       len(items),
+			"activations": items,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handleDeactivateWorkflow stops the automatic triggers of a workflow
+func (s *Server) handleDeactivateWorkflow(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	client := RedisClient.GetClient()
+
+	act, err := activation.Get(client, id)
 	if err != nil {
-		s.logger.Error("Failed to marshal workflow to JSON",
-			zap.String("request_id", requestID),
-			zap.Error(err),
-		)
+		s.writeErrorResponse(w, http.StatusNotFound, "Activation not found", fmt.Sprintf("Workflow %s is not active", id))
 		return
 	}
 
-	s.logger.Debug("Workflow JSON formatted",
-		zap.String("request_id", requestID),
-		zap.String("workflow_id", workflowComplete.ID),
-	)
+	if _, err := activation.Delete(client, id); err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to deactivate workflow", err.Error())
+		return
+	}
+	if err := scheduler.ResetCronState(client, act); err != nil {
+		s.logger.Warn("Failed to reset cron state", zap.String("workflow_id", id), zap.Error(err))
+	}
+	if err := webhook.UnregisterActivation(client, act); err != nil {
+		s.logger.Warn("Failed to release webhook paths", zap.String("workflow_id", id), zap.Error(err))
+	}
 
-	// Save to Redis
-	if err := s.saveWorkflowToRedis(jsonData, requestID); err != nil {
-		s.logger.Error("Failed to save workflow to Redis",
+	s.logger.Info("Workflow deactivated", zap.String("workflow_id", id))
+
+	response := APIResponse{
+		Status:  "success",
+		Message: "Workflow deactivated successfully",
+		Data: map[string]interface{}{
+			"id": id,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// handlePublishEvent starts every activated workflow listening to the event
+func (s *Server) handlePublishEvent(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	name := chi.URLParam(r, "name")
+
+	if _, err := event.ParseName(map[string]interface{}{"event": name}); err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid event name", err.Error())
+		return
+	}
+
+	// The body is the event payload; an empty body is allowed
+	var payload interface{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+		return
+	}
+
+	dispatched, err := event.Dispatch(RedisClient.GetClient(), name, payload)
+	if err != nil {
+		s.logger.Error("Failed to dispatch event",
 			zap.String("request_id", requestID),
+			zap.String("event", name),
 			zap.Error(err),
 		)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to dispatch event", err.Error())
 		return
 	}
 
-	s.logger.Info("Workflow successfully processed and saved",
+	s.logger.Info("Event published",
 		zap.String("request_id", requestID),
-		zap.String("workflow_id", workflowComplete.ID),
+		zap.String("event", name),
+		zap.Int("run_count", len(dispatched)),
 	)
 
-	// TODO: Queue for execution
-	// s.queueWorkflowForExecution(workflowComplete, requestID)
+	response := APIResponse{
+		Status:  "success",
+		Message: fmt.Sprintf("Event dispatched to %d trigger(s)", len(dispatched)),
+		Data: map[string]interface{}{
+			"event": name,
+			"runs":  dispatched,
+		},
+	}
+
+	s.writeJSONResponse(w, http.StatusAccepted, response)
 }
 
-// saveWorkflowToRedis handles Redis storage with proper error handling
-func (s *Server) saveWorkflowToRedis(jsonData []byte, requestID string) error {
+// handleWebhook starts a run of the activated workflow owning the requested hook path
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	path := webhook.NormalizePath(chi.URLParam(r, "*"))
 	client := RedisClient.GetClient()
-	if client == nil {
-		return fmt.Errorf("redis client not initialized")
+
+	route, err := webhook.Lookup(client, path)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "Webhook not found", fmt.Sprintf("No active webhook for path /hooks/%s", path))
+		return
 	}
-	_, err := client.LPush("workflows", string(jsonData))
+
+	act, err := activation.Get(client, route.WorkflowID)
 	if err != nil {
-		return fmt.Errorf("failed to push to Redis: %w", err)
+		s.writeErrorResponse(w, http.StatusNotFound, "Webhook not found", fmt.Sprintf("Workflow %s is no longer active", route.WorkflowID))
+		return
 	}
-	return nil
-}
 
-// handleWorkflowValidation validates workflow without processing it
-func (s *Server) handleWorkflowValidation(w http.ResponseWriter, r *http.Request) {
-	// This endpoint can be used to validate workflows without processing them
-	// Useful for frontend validation or testing
-	var payload map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload", err.Error())
+	var trigger *activation.Trigger
+	for _, candidate := range act.TriggersOfType(webhook.TriggerNodeType) {
+		if candidate.NodeID == route.NodeID {
+			trigger = &candidate
+			break
+		}
+	}
+	if trigger == nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "Webhook not found", fmt.Sprintf("No active webhook for path /hooks/%s", path))
 		return
 	}
 
-	// Validate using parser (dry run)
-	_, err := parser.ParseWorkflow(payload)
+	spec, err := webhook.ParseSpec(trigger.Data)
 	if err != nil {
-		s.writeErrorResponse(w, http.StatusUnprocessableEntity, "Workflow validation failed", err.Error())
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Invalid webhook trigger", err.Error())
 		return
 	}
 
-	response := APIResponse{
-		Status:  "success",
-		Message: "Workflow validation passed",
+	if !spec.Allows(r.Method) {
+		w.Header().Set("Allow", strings.Join(spec.Methods, ", "))
+		s.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed",
+			fmt.Sprintf("Webhook /hooks/%s accepts %s", path, strings.Join(spec.Methods, ", ")))
+		return
 	}
 
-	s.writeJSONResponse(w, http.StatusOK, response)
-}
+	input, err := webhook.RequestInput(r, path)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, "Invalid webhook request", err.Error())
+		return
+	}
 
+	job := act.NewJob(*trigger, input)
+	if err := runs.Enqueue(client, job); err != nil {
+		s.logger.Error("Failed to enqueue webhook run",
+			zap.String("request_id", requestID),
+			zap.String("workflow_id", act.WorkflowID),
+			zap.Error(err),
+		)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to start workflow", err.Error())
+		return
+	}
 
-func (s *Server) handleGetWorkflow(w http.ResponseWriter, r *http.Request) {
-    id := chi.URLParam(r, "id")
-    if id == "" {
-        s.writeErrorResponse(w, http.StatusBadRequest, "Missing workflow ID", "ID parameter is required")
-        return
-    }
+	s.logger.Info("Webhook triggered",
+		zap.String("request_id", requestID),
+		zap.String("workflow_id", act.WorkflowID),
+		zap.String("node_id", trigger.NodeID),
+		zap.String("run_id", job.RunID),
+		zap.String("mode", string(spec.Mode)),
+	)
 
-    client := RedisClient.GetClient()
-    if client == nil {
-        s.writeErrorResponse(w, http.StatusInternalServerError, "Redis client not initialized", "Failed to connect to Redis")
-        return
-    }
+	accepted := APIResponse{
+		Status:  "success",
+		Message: "Workflow run queued",
+		Data: map[string]interface{}{
+			"id":     act.WorkflowID,
+			"run_id": job.RunID,
+		},
+	}
 
-    // Retrieve workflow from Redis
-    listId := "workflow:" + id + ":results"
-    res, err := client.GetFirstKey(listId)
-    if err != nil {
-        s.writeErrorResponse(w, http.StatusNotFound, "Workflow not found", fmt.Sprintf("No workflow found with ID %s", id))
-        return
-    }
+	if spec.Mode == webhook.ModeAsync {
+		s.writeJSONResponse(w, http.StatusAccepted, accepted)
+		return
+	}
 
-    // 🎯 Structure clean - directement les données utiles
-    response := APIResponse{
-        Status:  "success",
-        Message: "Workflow retrieved successfully",
-        Data: map[string]interface{}{
-            "id":      id,
-            "results": res,
-        },
-    }
-    
-    s.writeJSONResponse(w, http.StatusOK, response)
-}
+	// Synchronous mode: hold the response until the run finishes
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(spec.Timeout + 5*time.Second))
+
+	rawResult, err := queue.WaitResult(r.Context(), job.RunID, spec.Timeout)
+	if errors.Is(err, queue.ErrResultTimeout) {
+		accepted.Message = "Workflow run still in progress"
+		s.writeJSONResponse(w, http.StatusAccepted, accepted)
+		return
+	}
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Failed to wait for workflow run", err.Error())
+		return
+	}
+
+	output, err := webhook.LastNodeOutput(rawResult)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadGateway, "Workflow run failed", err.Error())
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Run-ID", job.RunID)
+	w.WriteHeader(http.StatusOK)
+	w.Write(output)
+}
 
 // validateWorkflowPayload performs basic payload structure validation
 func (s *Server) validateWorkflowPayload(payload map[string]interface{}) error {
@@ -399,6 +2516,14 @@ func (s *Server) Start() error {
 		IdleTimeout:  IdleTimeout,
 	}
 
+	// Background loops share a context cancelled on shutdown
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+
+	go scheduler.NewPromoter(RedisClient.GetClient(), scheduler.DefaultPromoteInterval).Run(bgCtx)
+	go s.cron.Run(bgCtx)
+	go waits.NewWatcher(RedisClient.GetClient(), waits.DefaultWatchInterval).Run(bgCtx)
+
 	// Channel to listen for interrupt signals
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -419,6 +2544,7 @@ func (s *Server) Start() error {
 	<-stop
 
 	s.logger.Info("Shutting down server gracefully...")
+	bgCancel()
 
 	// Create shutdown context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
@@ -466,6 +2592,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Durable storage for workflows, revisions and run history
+	store := storage.GetStore()
+	if store == nil {
+		logger.Log.Fatal("Failed to initialize storage")
+		os.Exit(1)
+	}
+	defer store.Close()
+
 	logger.Log.Info("Initializing Worker Manager API Server",
 		zap.String("version", "1.0.0"),
 		zap.Time("start_time", time.Now()),
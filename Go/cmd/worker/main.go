@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -14,6 +15,10 @@ import (
 	"go.uber.org/zap"
 
 	"XKA/internal/shared/builder"
+	"XKA/internal/shared/queue"
+	"XKA/internal/shared/runs"
+	"XKA/internal/shared/storage"
+	"XKA/internal/shared/waits"
 	"XKA/internal/worker/runner"
 	"XKA/pkg/RedisClient"
 	"XKA/pkg/logger"
@@ -23,7 +28,6 @@ import (
 const (
 	maxRetries = 3
 	retryDelay = 5 * time.Second
-	queueName  = "workflows"
 	popTimeout = 5 * time.Second
 )
 
@@ -57,6 +61,13 @@ func main() {
 		logger.Log.Fatal("Failed to initialize Redis client", zap.Error(err))
 	}
 
+	// Durable storage for run history and node results
+	store := storage.GetStore()
+	if store == nil {
+		logger.Log.Fatal("Failed to initialize storage")
+	}
+	defer store.Close()
+
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -105,12 +116,16 @@ func handleShutdown(cancel context.CancelFunc) {
 }
 
 func runWorker(ctx context.Context, client *RedisClient.Client) {
+	// popTick drives the weighted round-robin between priority queues
+	var popTick uint64
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			if err := processJob(client); err != nil {
+			popTick++
+			if err := processJob(client, popTick); err != nil {
 				// logger.Log.Error("Failed to process job", zap.Error(err))
 				// time.Sleep(retryDelay)
 			}
@@ -118,41 +133,83 @@ func runWorker(ctx context.Context, client *RedisClient.Client) {
 	}
 }
 
-func processJob(client *RedisClient.Client) error {
+func processJob(client *RedisClient.Client, popTick uint64) error {
 	// Check Redis connection
 	if err := client.Ping(); err != nil {
 		return err
 	}
 
-	// Pop job from queue (blocking operation)
-	job, err := client.BRPop(popTimeout, queueName)
+	// Paused queues are skipped; with every queue paused the worker idles
+	keys := queue.Poppable(client, queue.PopOrder(popTick))
+	if len(keys) == 0 {
+		time.Sleep(queue.PausedPollInterval)
+		return nil
+	}
+
+	// Pop job from the priority queues (blocking operation)
+	entry, err := client.BRPop(popTimeout, keys...)
 	if err != nil {
 		return err
 	}
 
 	// No job available (timeout)
-	if job == nil {
+	if entry == nil {
 		// logger.Log.Debug("No job available, continuing...")
 		return nil
 	}
 
 	// Job found
 	logger.Log.Debug("Job received",
-		zap.String("queue", job[0]),
-		zap.String("job", job[1]),
+		zap.String("queue", entry[0]),
+		zap.String("job", entry[1]),
+	)
+
+	job, err := queue.DecodeEntry(entry[0], entry[1])
+	if err != nil {
+		logger.Log.Error("Failed to decode job", zap.Error(err))
+		return err
+	}
+
+	if job.Kind == queue.KindNode {
+		return processNodeJob(client, job)
+	}
+
+	logger.Log.Info("Processing job",
+		zap.String("run_id", job.RunID),
+		zap.String("workflow_id", job.WorkflowID),
+		zap.String("priority", job.Priority.String()),
+		zap.Duration("queued_for", time.Since(time.Unix(job.EnqueuedAt, 0))),
 	)
 
-	// TODO: Process job here
-	// processJobData(job[1])
+	if err := runs.Start(client, job); errors.Is(err, runs.ErrFinished) {
+		logger.Log.Info("Run already finished, job dropped", zap.String("run_id", job.RunID))
+		return nil
+	} else if err != nil {
+		logger.Log.Warn("Failed to mark run as running", zap.String("run_id", job.RunID), zap.Error(err))
+	}
 
-	workflow, err := builder.ParseWorkflowFromJSON( job[1])
+	workflow, err := builder.ParseWorkflowFromBytes(job.Workflow)
 	if err != nil {
 		logger.Log.Error("Failed to parse workflow from JSON", zap.Error(err))
+		if finishErr := runs.Finish(client, job.RunID, runs.StatusError, err.Error()); finishErr != nil {
+			logger.Log.Warn("Failed to record run outcome", zap.String("run_id", job.RunID), zap.Error(finishErr))
+		}
 		return err
 	}
 
-	testID := "test_" + time.Now().Format("20060102150405")
-	wRes, err := runner.Run(workflow, testID)
+	wRes, err := runner.Run(workflow, &runner.RunContext{
+		RunID:         job.RunID,
+		TriggerNodeID: job.TriggerNodeID,
+		Input:         job.Input,
+		RetryOf:       job.RetryOf,
+		Reuse:         job.Reuse,
+		Development:   job.Mode == queue.ModeDevelopment || job.Mode == queue.ModeDebug,
+		Debug:         job.Mode == queue.ModeDebug,
+		Breakpoints:   breakpoints(job.Breakpoints),
+		Restore:       job.Restore,
+		Resume:        job.Resume,
+		Resumed:       job.Resumed,
+	})
 	if err != nil {
 		logger.Log.Error("Failed to run workflow", zap.Error(err))
 	}
@@ -164,5 +221,158 @@ func processJob(client *RedisClient.Client) error {
 
 	fmt.Println("Workflow Result:", string(jsonData))
 
+	// Persist the node results before marking the run as finished
+	if err := runs.SaveResults(job.RunID, nodeResults(wRes)); err != nil {
+		logger.Log.Warn("Failed to persist node results", zap.String("run_id", job.RunID), zap.Error(err))
+	}
+
+	// Suspended and paused runs are resumed later, possibly on another worker
+	if wRes.Waiting != nil {
+		suspendRun(client, job, wRes)
+		return nil
+	}
+
+	// Record the outcome in the run history
+	status, errMsg := runs.StatusSuccess, ""
+	if wRes.Status != string(runs.StatusSuccess) {
+		status = runs.StatusError
+		if wRes.Status == string(runs.StatusCancelled) {
+			status = runs.StatusCancelled
+		}
+		if wRes.Error != nil {
+			errMsg = *wRes.Error
+		}
+	}
+	if err := runs.Finish(client, job.RunID, status, errMsg); err != nil {
+		logger.Log.Warn("Failed to record run outcome", zap.String("run_id", job.RunID), zap.Error(err))
+	}
+
+	// Hand the final result to callers waiting synchronously on the run
+	if err := queue.PublishResult(client, job.RunID, jsonData); err != nil {
+		logger.Log.Error("Failed to publish run result", zap.String("run_id", job.RunID), zap.Error(err))
+	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+// suspendRun records the wait of a run suspended by a node or paused by an operator.
+// The wait holds the job to push again on resumption, with the responses of the
+// nodes executed so far.
+func suspendRun(client *RedisClient.Client, job *queue.Job, wRes *runner.WorkflowExecutionResult) {
+	resume := *job
+	reason := waits.ReasonEvent
+	if wRes.Waiting.Paused {
+		reason = waits.ReasonPaused
+	}
+	// A wait resolved right before a pause is handed to its node on resumption
+	if !wRes.Waiting.Paused || job.Resume == nil || job.Resume.NodeID != wRes.Waiting.NodeID {
+		resume.Resume = nil
+	}
+	resume.Restore = make([]json.RawMessage, 0, len(wRes.Nodes))
+	for i, node := range wRes.Nodes {
+		if i == wRes.Waiting.Position {
+			continue // Executed again on resumption
+		}
+		data, err := json.Marshal(node)
+		if err != nil {
+			logger.Log.Warn("Failed to marshal node response", zap.String("run_id", job.RunID), zap.Error(err))
+			continue
+		}
+		resume.Restore = append(resume.Restore, data)
+	}
+
+	err := waits.Suspend(client, &waits.Wait{
+		Reason:         reason,
+		RunID:          job.RunID,
+		WorkflowID:     job.WorkflowID,
+		NodeID:         wRes.Waiting.NodeID,
+		NodeType:       wRes.Waiting.NodeType,
+		CorrelationKey: wRes.Waiting.CorrelationKey,
+		TimeoutAt:      wRes.Waiting.TimeoutAt,
+		Job:            &resume,
+	})
+	if err != nil {
+		logger.Log.Error("Failed to suspend run", zap.String("run_id", job.RunID), zap.Error(err))
+		if finishErr := runs.Finish(client, job.RunID, runs.StatusError, "failed to suspend run: "+err.Error()); finishErr != nil {
+			logger.Log.Warn("Failed to record run outcome", zap.String("run_id", job.RunID), zap.Error(finishErr))
+		}
+		return
+	}
+
+	logger.Log.Info("Run suspended",
+		zap.String("run_id", job.RunID),
+		zap.String("reason", reason),
+		zap.String("node_id", wRes.Waiting.NodeID),
+		zap.Int64("timeout_at", wRes.Waiting.TimeoutAt),
+	)
+}
+
+// breakpoints indexes the breakpoint node IDs of a debug run
+func breakpoints(nodeIDs []string) map[string]bool {
+	set := make(map[string]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		set[id] = true
+	}
+	return set
+}
+
+// processNodeJob executes a single node for testing and publishes its response.
+// Node jobs have no run record, events or stored results.
+func processNodeJob(client *RedisClient.Client, job *queue.Job) error {
+	var node builder.Node
+	if err := json.Unmarshal(job.Node, &node); err != nil {
+		logger.Log.Error("Failed to decode node job", zap.String("job_id", job.RunID), zap.Error(err))
+		return err
+	}
+
+	logger.Log.Info("Executing node job",
+		zap.String("job_id", job.RunID),
+		zap.String("node_type", node.Type),
+		zap.Duration("queued_for", time.Since(time.Unix(job.EnqueuedAt, 0))),
+	)
+
+	resp := runner.ExecuteNode(&node, &runner.RunContext{
+		WorkflowID: job.WorkflowID,
+		Input:      job.Input,
+	})
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		logger.Log.Error("Failed to marshal node response", zap.String("job_id", job.RunID), zap.Error(err))
+		return err
+	}
+	if err := queue.PublishResult(client, job.RunID, data); err != nil {
+		logger.Log.Error("Failed to publish node response", zap.String("job_id", job.RunID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// nodeResults converts the node responses of a run for the durable store
+func nodeResults(wRes *runner.WorkflowExecutionResult) []storage.NodeResult {
+	results := make([]storage.NodeResult, 0, len(wRes.Nodes))
+	for i, node := range wRes.Nodes {
+		output, err := json.Marshal(node.Result)
+		if err != nil {
+			output = []byte("null")
+		}
+
+		result := storage.NodeResult{
+			RunID:      wRes.RunID,
+			Position:   i,
+			NodeID:     node.NodeID,
+			NodeType:   node.NodeType,
+			Status:     node.Status,
+			StartedAt:  node.Timestamp,
+			DurationMs: node.DurationMs,
+			Result:     output,
+			Logs:       node.Logs,
+			Branch:     node.Branch,
+		}
+		if node.Error != nil {
+			result.Error = *node.Error
+		}
+		results = append(results, result)
+	}
+	return results
+}
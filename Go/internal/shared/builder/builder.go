@@ -2,20 +2,24 @@
 package builder
 
 import (
+	"XKA/internal/shared/nodetypes"
+	"XKA/internal/shared/schema"
 	"XKA/internal/worker-manager/parser"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // Node represents a processed, execution-ready workflow node.
 // Contains navigation IDs and execution state for workflow processing.
 type Node struct {
-	ID            string                 `json:"id"`            // Unique node identifier
-	Type          string                 `json:"type"`          // Node type for execution logic
-	Data          map[string]interface{} `json:"data"`          // Node configuration and parameters
-	NextIDs       []string               `json:"nextIds"`       // IDs of subsequent nodes
-	PreviousIDs   []string               `json:"previousIds"`   // IDs of previous nodes
-	InitialInputs int                    `json:"initialInputs"` // Number of expected inputs for execution
+	ID            string                 `json:"id"`                    // Unique node identifier
+	Type          string                 `json:"type"`                  // Node type for execution logic
+	Data          map[string]interface{} `json:"data"`                  // Node configuration and parameters
+	NextIDs       []string               `json:"nextIds"`               // IDs of subsequent nodes
+	PreviousIDs   []string               `json:"previousIds"`           // IDs of previous nodes
+	InitialInputs int                    `json:"initialInputs"`         // Number of expected inputs for execution
+	NextHandles   map[string]string      `json:"nextHandles,omitempty"` // Source handle of the edge to each next node, when set
 }
 
 // Workflow represents the complete processed workflow graph.
@@ -24,8 +28,22 @@ type Workflow struct {
 	ID           string           `json:"id"`           // Unique workflow identifier
 	NodeMap      map[string]*Node `json:"nodeMap"`      // Fast lookup table for nodes by ID
 	StartNodeIDs []string         `json:"startNodeIds"` // IDs of entry points for workflow execution
+
+	Warnings []parser.Issue `json:"-"` // Warnings found by InitWorkflow (not serialized)
 }
 
+// InputSchemaField is the data field of a trigger node declaring, as a JSON Schema,
+// the input expected by runs started from it.
+const InputSchemaField = "inputSchema"
+
+// PinnedDataField is the data field holding the pinned output of a node.
+// In development runs a pinned node is not executed: its pinned output is used instead.
+const PinnedDataField = "pinnedData"
+
+// BreakpointField is the data field flagging a node as a breakpoint:
+// debug runs pause before executing it.
+const BreakpointField = "breakpoint"
+
 // WorkflowError represents workflow validation and processing errors.
 type WorkflowError struct {
 	Field   string
@@ -49,6 +67,30 @@ func (n *Node) GetNextNodes(workflow *Workflow) []*Node {
 	return nodes
 }
 
+// BranchNextIDs returns the next nodes followed when the node takes a branch.
+// An edge belongs to a branch when its source handle names one of the branches
+// declared by the node type; every other edge belongs to the default branch ("").
+func (n *Node) BranchNextIDs(branch string) []string {
+	def, _ := nodetypes.Lookup(n.Type)
+
+	ids := make([]string, 0, len(n.NextIDs))
+	for _, id := range n.NextIDs {
+		handle := n.NextHandles[id]
+		if !def.HasBranch(handle) {
+			handle = ""
+		}
+		if handle == branch {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// HasBranch reports whether at least one edge leaves the node on a branch.
+func (n *Node) HasBranch(branch string) bool {
+	return len(n.BranchNextIDs(branch)) > 0
+}
+
 // GetPreviousNodes returns the actual Node objects for the previous nodes.
 // Helper method to navigate the workflow graph.
 func (n *Node) GetPreviousNodes(workflow *Workflow) []*Node {
@@ -61,6 +103,71 @@ func (n *Node) GetPreviousNodes(workflow *Workflow) []*Node {
 	return nodes
 }
 
+// InputSchema returns the run input schema declared on a trigger node, nil if none.
+func (n *Node) InputSchema() (*schema.Schema, error) {
+	raw, ok := n.Data[InputSchemaField]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	return schema.Parse(raw)
+}
+
+// ValidateInput applies the input schema of a trigger node to the input of a run:
+// defaults are filled in and every problem is reported with its location.
+// A node without schema accepts any input.
+func (n *Node) ValidateInput(input map[string]interface{}) (map[string]interface{}, schema.Errors, error) {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+
+	inputSchema, err := n.InputSchema()
+	if err != nil || inputSchema == nil {
+		return input, nil, err
+	}
+
+	value, errs := inputSchema.Apply(input)
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+	validated, _ := value.(map[string]interface{})
+	return validated, nil, nil
+}
+
+// PinnedData returns the pinned output of a node, false if it has none.
+func (n *Node) PinnedData() (interface{}, bool) {
+	value, ok := n.Data[PinnedDataField]
+	return value, ok && value != nil
+}
+
+// IsBreakpoint reports whether debug runs pause before the node.
+func (n *Node) IsBreakpoint() bool {
+	flagged, _ := n.Data[BreakpointField].(bool)
+	return flagged
+}
+
+// WithoutPinnedData returns a copy of the workflow whose nodes carry no pinned output.
+// Nodes without pinned data are shared with the original workflow.
+func (w *Workflow) WithoutPinnedData() *Workflow {
+	stripped := *w
+	stripped.NodeMap = make(map[string]*Node, len(w.NodeMap))
+	for id, node := range w.NodeMap {
+		if _, pinned := node.Data[PinnedDataField]; !pinned {
+			stripped.NodeMap[id] = node
+			continue
+		}
+
+		copied := *node
+		copied.Data = make(map[string]interface{}, len(node.Data))
+		for key, value := range node.Data {
+			if key != PinnedDataField {
+				copied.Data[key] = value
+			}
+		}
+		stripped.NodeMap[id] = &copied
+	}
+	return &stripped
+}
+
 // GetStartNodes returns the actual Node objects for the start nodes.
 // Helper method to get workflow entry points.
 func (w *Workflow) GetStartNodes() []*Node {
@@ -75,6 +182,9 @@ func (w *Workflow) GetStartNodes() []*Node {
 
 // InitWorkflow transforms raw parsed payload into optimized workflow graph.
 // Validates structure and builds node relationships for execution.
+// Every problem found (missing trigger, invalid input schema, cycle...) is
+// returned together as a *parser.ValidationError; warnings such as unreachable
+// nodes are kept in Workflow.Warnings.
 func InitWorkflow(payload *parser.Payload) (*Workflow, error) {
 	if payload == nil {
 		return nil, &WorkflowError{
@@ -118,34 +228,101 @@ func InitWorkflow(payload *parser.Payload) (*Workflow, error) {
 		// Establish connections using IDs
 		sourceNode.NextIDs = append(sourceNode.NextIDs, targetNode.ID)
 		targetNode.PreviousIDs = append(targetNode.PreviousIDs, sourceNode.ID)
+		if edge.SourceHandle != "" {
+			if sourceNode.NextHandles == nil {
+				sourceNode.NextHandles = make(map[string]string)
+			}
+			sourceNode.NextHandles[targetNode.ID] = edge.SourceHandle
+		}
 	}
 
 	for _, node := range workflow.NodeMap {
 		node.InitialInputs = len(node.PreviousIDs)
 	}
 
-	// Validate manual start node requirements
-	startNodes := workflow.FindNodesByType("manualStartNode")
-	switch len(startNodes) {
-	case 0:
-		return nil, &WorkflowError{
-			Field:   "workflow",
-			Message: "no manual start node found",
+	issues := make([]parser.Issue, 0)
+
+	// Every trigger node is an entry point; a run starts from exactly one of them
+	for _, id := range workflow.sortedNodeIDs() {
+		node := workflow.NodeMap[id]
+		if nodetypes.IsTrigger(node.Type) {
+			node.InitialInputs = 0        // Trigger nodes do not require inputs
+			node.PreviousIDs = []string{} // No previous nodes for a trigger
+			workflow.StartNodeIDs = append(workflow.StartNodeIDs, node.ID)
+
+			if _, err := node.InputSchema(); err != nil {
+				issues = append(issues, parser.Issue{
+					Path:     payload.NodePointer(node.ID, "data/"+InputSchemaField),
+					Code:     parser.CodeInvalidSchema,
+					Severity: parser.SeverityError,
+					Message:  err.Error(),
+					NodeID:   node.ID,
+				})
+			}
+		}
+	}
+	if len(workflow.StartNodeIDs) == 0 {
+		issues = append(issues, parser.Issue{
+			Path:     "/nodes",
+			Code:     parser.CodeNoTrigger,
+			Severity: parser.SeverityError,
+			Message:  fmt.Sprintf("no trigger node found (expected one of: %s)", strings.Join(nodetypes.TriggerTypes(), ", ")),
+		})
+	}
+
+	// Reject unintended cycles, report the nodes no trigger leads to
+	issues = append(issues, workflow.graphIssues(payload)...)
+
+	if parser.HasErrors(issues) {
+		return nil, &parser.ValidationError{Issues: issues}
+	}
+	if len(issues) > 0 {
+		workflow.Warnings = issues
+	}
+
+	return workflow, nil
+}
+
+// SelectStartNode picks the trigger a run starts from.
+// An explicit nodeID must be a start node; otherwise the single manual trigger
+// is used, or the only trigger if the workflow has just one.
+func (w *Workflow) SelectStartNode(nodeID string) (*Node, error) {
+	if nodeID != "" {
+		for _, startID := range w.StartNodeIDs {
+			if startID == nodeID {
+				return w.FindNodeByID(startID), nil
+			}
 		}
-	case 1:
-		startNodes[0].InitialInputs = 0        // Manual start nodes do not require inputs
-		startNodes[0].PreviousIDs = []string{} // No previous nodes for manual start
-		workflow.StartNodeIDs = []string{startNodes[0].ID}
-	default:
 		return nil, &WorkflowError{
-			Field:   "workflow",
-			Message: "multiple manual start nodes found",
+			Field:   "triggerNodeId",
+			Message: fmt.Sprintf("node %s is not a trigger of the workflow", nodeID),
 		}
 	}
 
-	// TODO: Validate graph structure (cycles, unreachable nodes, etc.)
+	if len(w.StartNodeIDs) == 1 {
+		return w.FindNodeByID(w.StartNodeIDs[0]), nil
+	}
 
-	return workflow, nil
+	var manual *Node
+	for _, node := range w.GetStartNodes() {
+		if nodetypes.TriggerKindOf(node.Type) != nodetypes.TriggerManual {
+			continue
+		}
+		if manual != nil {
+			return nil, &WorkflowError{
+				Field:   "triggerNodeId",
+				Message: "workflow has several manual triggers, triggerNodeId is required",
+			}
+		}
+		manual = node
+	}
+	if manual == nil {
+		return nil, &WorkflowError{
+			Field:   "triggerNodeId",
+			Message: "workflow has several triggers and none is manual, triggerNodeId is required",
+		}
+	}
+	return manual, nil
 }
 
 // FindNodeByID performs O(1) lookup of node by unique identifier.
@@ -332,22 +509,27 @@ func validateDeserializedWorkflow(workflow *Workflow) error {
 		}
 	}
 
-	// Validate that start nodes are actually manual start nodes (if following the original logic)
+	// Validate that start nodes are registered trigger types
 	for _, startID := range workflow.StartNodeIDs {
 		startNode := workflow.NodeMap[startID]
-		if startNode.Type == "manualStartNode" {
-			// Ensure manual start nodes have no previous nodes and initialInputs is 0
-			if len(startNode.PreviousIDs) != 0 {
-				return &WorkflowError{
-					Field:   "startNode.PreviousIDs",
-					Message: fmt.Sprintf("manual start node %s should not have previous nodes", startID),
-				}
+		if !nodetypes.IsTrigger(startNode.Type) {
+			return &WorkflowError{
+				Field:   "startNodeIds",
+				Message: fmt.Sprintf("node %s of type %s is not a trigger", startID, startNode.Type),
 			}
-			if startNode.InitialInputs != 0 {
-				return &WorkflowError{
-					Field:   "startNode.InitialInputs",
-					Message: fmt.Sprintf("manual start node %s should have InitialInputs = 0", startID),
-				}
+		}
+
+		// Ensure start nodes have no previous nodes and initialInputs is 0
+		if len(startNode.PreviousIDs) != 0 {
+			return &WorkflowError{
+				Field:   "startNode.PreviousIDs",
+				Message: fmt.Sprintf("start node %s should not have previous nodes", startID),
+			}
+		}
+		if startNode.InitialInputs != 0 {
+			return &WorkflowError{
+				Field:   "startNode.InitialInputs",
+				Message: fmt.Sprintf("start node %s should have InitialInputs = 0", startID),
 			}
 		}
 	}
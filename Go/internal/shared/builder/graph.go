@@ -0,0 +1,148 @@
+package builder
+
+import (
+	"XKA/internal/worker-manager/parser"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// graphIssues checks the structure of a workflow graph and returns every issue found:
+//   - nodes of a cycle are errors: cycles are not supported, the runner would execute them forever
+//   - nodes no trigger leads to are warnings: they are never executed
+//
+// Issues point into the payload the workflow was built from.
+func (w *Workflow) graphIssues(payload *parser.Payload) []parser.Issue {
+	issues := make([]parser.Issue, 0)
+	for _, component := range w.stronglyConnected() {
+		if !w.isCycle(component) {
+			continue
+		}
+		for _, id := range component {
+			issues = append(issues, parser.Issue{
+				Path:     payload.NodePointer(id, ""),
+				Code:     parser.CodeCycle,
+				Severity: parser.SeverityError,
+				Message:  fmt.Sprintf("node %s is part of a cycle between nodes %s", id, strings.Join(component, ", ")),
+				NodeID:   id,
+			})
+		}
+	}
+
+	if len(w.StartNodeIDs) == 0 {
+		return issues // Reported as a missing trigger instead
+	}
+	for _, id := range w.unreachableNodes() {
+		issues = append(issues, parser.Issue{
+			Path:     payload.NodePointer(id, ""),
+			Code:     parser.CodeUnreachable,
+			Severity: parser.SeverityWarning,
+			Message:  fmt.Sprintf("node %s is not reachable from any trigger", id),
+			NodeID:   id,
+		})
+	}
+	return issues
+}
+
+// stronglyConnected returns the strongly connected components of the graph
+// (Tarjan), each sorted, in a deterministic order.
+func (w *Workflow) stronglyConnected() [][]string {
+	ids := w.sortedNodeIDs()
+
+	index := make(map[string]int, len(ids))
+	lowlink := make(map[string]int, len(ids))
+	onStack := make(map[string]bool, len(ids))
+	stack := make([]string, 0, len(ids))
+	components := make([][]string, 0)
+	next := 0
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id], lowlink[id] = next, next
+		next++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, nextID := range w.NodeMap[id].NextIDs {
+			if _, seen := index[nextID]; !seen {
+				visit(nextID)
+				lowlink[id] = min(lowlink[id], lowlink[nextID])
+			} else if onStack[nextID] {
+				lowlink[id] = min(lowlink[id], index[nextID])
+			}
+		}
+
+		if lowlink[id] != index[id] {
+			return
+		}
+		component := make([]string, 0)
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+
+	for _, id := range ids {
+		if _, seen := index[id]; !seen {
+			visit(id)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	return components
+}
+
+// isCycle reports whether a strongly connected component contains a cycle:
+// several nodes, or a single node linked to itself.
+func (w *Workflow) isCycle(component []string) bool {
+	if len(component) > 1 {
+		return true
+	}
+	for _, nextID := range w.NodeMap[component[0]].NextIDs {
+		if nextID == component[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// unreachableNodes returns the sorted IDs of the nodes no start node leads to.
+func (w *Workflow) unreachableNodes() []string {
+	reached := make(map[string]bool, len(w.NodeMap))
+	queue := append([]string(nil), w.StartNodeIDs...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if reached[id] {
+			continue
+		}
+		reached[id] = true
+		if node := w.NodeMap[id]; node != nil {
+			queue = append(queue, node.NextIDs...)
+		}
+	}
+
+	unreachable := make([]string, 0)
+	for _, id := range w.sortedNodeIDs() {
+		if !reached[id] {
+			unreachable = append(unreachable, id)
+		}
+	}
+	return unreachable
+}
+
+// sortedNodeIDs returns the IDs of every node, sorted.
+func (w *Workflow) sortedNodeIDs() []string {
+	ids := make([]string, 0, len(w.NodeMap))
+	for id := range w.NodeMap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,188 @@
+package builder
+
+import (
+	"XKA/internal/shared/nodetypes"
+	"XKA/internal/worker-manager/parser"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testPayload builds a payload from "id:type" nodes and "source>target" edges.
+func testPayload(nodes []string, edges ...string) *parser.Payload {
+	payload := &parser.Payload{}
+	for _, node := range nodes {
+		id, nodeType, _ := strings.Cut(node, ":")
+		payload.Nodes = append(payload.Nodes, parser.RawNode{ID: id, Type: nodeType})
+	}
+	for _, edge := range edges {
+		source, target, _ := strings.Cut(edge, ">")
+		payload.Edges = append(payload.Edges, parser.RawEdge{ID: edge, Source: source, Target: target})
+	}
+	return payload
+}
+
+// testGraph builds the graph of a payload without validating it.
+func testGraph(nodes []string, edges ...string) *Workflow {
+	payload := testPayload(nodes, edges...)
+	w := &Workflow{NodeMap: map[string]*Node{}}
+	for _, raw := range payload.Nodes {
+		w.NodeMap[raw.ID] = &Node{ID: raw.ID, Type: raw.Type}
+		if nodetypes.IsTrigger(raw.Type) {
+			w.StartNodeIDs = append(w.StartNodeIDs, raw.ID)
+		}
+	}
+	for _, edge := range payload.Edges {
+		w.NodeMap[edge.Source].NextIDs = append(w.NodeMap[edge.Source].NextIDs, edge.Target)
+	}
+	return w
+}
+
+func TestStronglyConnected(t *testing.T) {
+	nodes := []string{"a:" + nodetypes.ManualStartNode, "b:x", "c:x", "d:x", "e:x", "f:x"}
+
+	tests := []struct {
+		name  string
+		edges []string
+		want  [][]string
+	}{
+		{"chain", []string{"a>b", "b>c"}, [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}, {"f"}}},
+		{"cycle", []string{"a>b", "b>c", "c>b"}, [][]string{{"a"}, {"b", "c"}, {"d"}, {"e"}, {"f"}}},
+		{"self-loop", []string{"a>b", "b>b"}, [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}, {"f"}}},
+		{"two cycles", []string{"a>b", "b>c", "c>a", "d>e", "e>f", "f>d"}, [][]string{{"a", "b", "c"}, {"d", "e", "f"}}},
+		{"nested cycles", []string{"a>b", "b>c", "c>b", "c>d", "d>a"}, [][]string{{"a", "b", "c", "d"}, {"e"}, {"f"}}},
+		{"diamond", []string{"a>b", "a>c", "b>d", "c>d"}, [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}, {"f"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testGraph(nodes, tt.edges...).stronglyConnected()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stronglyConnected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCycle(t *testing.T) {
+	w := testGraph([]string{"a:x", "b:x", "c:x"}, "a>b", "b>b")
+
+	tests := []struct {
+		component []string
+		want      bool
+	}{
+		{[]string{"a"}, false},
+		{[]string{"b"}, true}, // Self-loop
+		{[]string{"a", "c"}, true},
+	}
+	for _, tt := range tests {
+		if got := w.isCycle(tt.component); got != tt.want {
+			t.Errorf("isCycle(%v) = %v, want %v", tt.component, got, tt.want)
+		}
+	}
+}
+
+func TestUnreachableNodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []string
+		edges []string
+		want  []string
+	}{
+		{"all reached", []string{"a:" + nodetypes.ManualStartNode, "b:x", "c:x"}, []string{"a>b", "b>c"}, []string{}},
+		{"isolated node", []string{"a:" + nodetypes.ManualStartNode, "b:x", "c:x"}, []string{"a>b"}, []string{"c"}},
+		{"edge into the trigger only", []string{"a:" + nodetypes.ManualStartNode, "b:x", "c:x"}, []string{"a>b", "c>b"}, []string{"c"}},
+		{"unreachable cycle", []string{"a:" + nodetypes.ManualStartNode, "b:x", "c:x"}, []string{"b>c", "c>b"}, []string{"b", "c"}},
+		{"several triggers", []string{"a:" + nodetypes.ManualStartNode, "b:" + nodetypes.CronTriggerNode, "c:x", "d:x"}, []string{"a>c", "b>d"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testGraph(tt.nodes, tt.edges...).unreachableNodes()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unreachableNodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitWorkflowGraphIssues(t *testing.T) {
+	start := "start:" + nodetypes.ManualStartNode
+	http := func(id string) string { return id + ":" + nodetypes.HttpRequestNode }
+
+	tests := []struct {
+		name     string
+		payload  *parser.Payload
+		errors   []string // Code and pointer of each error
+		warnings []string // Code and pointer of each warning
+	}{
+		{
+			name:    "valid",
+			payload: testPayload([]string{start, http("a"), http("b")}, "start>a", "a>b"),
+		},
+		{
+			name:    "cycle",
+			payload: testPayload([]string{start, http("a"), http("b")}, "start>a", "a>b", "b>a"),
+			errors:  []string{"cycle /nodes/1", "cycle /nodes/2"},
+		},
+		{
+			name:    "self-loop",
+			payload: testPayload([]string{start, http("a")}, "start>a", "a>a"),
+			errors:  []string{"cycle /nodes/1"},
+		},
+		{
+			name:     "unreachable node",
+			payload:  testPayload([]string{start, http("a"), http("b")}, "start>a"),
+			warnings: []string{"unreachable /nodes/2"},
+		},
+		{
+			name:     "unreachable cycle",
+			payload:  testPayload([]string{start, http("a"), http("b")}, "a>b", "b>a"),
+			errors:   []string{"cycle /nodes/1", "cycle /nodes/2"},
+			warnings: []string{"unreachable /nodes/1", "unreachable /nodes/2"},
+		},
+		{
+			name:    "no trigger",
+			payload: testPayload([]string{http("a"), http("b")}, "a>b"),
+			errors:  []string{"no_trigger /nodes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := InitWorkflow(tt.payload)
+
+			var validation *parser.ValidationError
+			if len(tt.errors) > 0 {
+				if !errors.As(err, &validation) {
+					t.Fatalf("InitWorkflow() error = %v, want a validation error", err)
+				}
+				gotErrors, gotWarnings := issueCodes(validation.Issues)
+				if !reflect.DeepEqual(gotErrors, tt.errors) {
+					t.Errorf("errors = %v, want %v", gotErrors, tt.errors)
+				}
+				if !reflect.DeepEqual(gotWarnings, tt.warnings) {
+					t.Errorf("warnings = %v, want %v", gotWarnings, tt.warnings)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("InitWorkflow() error = %v", err)
+			}
+			if _, gotWarnings := issueCodes(w.Warnings); !reflect.DeepEqual(gotWarnings, tt.warnings) {
+				t.Errorf("warnings = %v, want %v", gotWarnings, tt.warnings)
+			}
+		})
+	}
+}
+
+// issueCodes splits issues into errors and warnings, each as "code pointer".
+func issueCodes(issues []parser.Issue) (errs, warnings []string) {
+	for _, issue := range issues {
+		if issue.Severity == parser.SeverityError {
+			errs = append(errs, issue.Code+" "+issue.Path)
+		} else {
+			warnings = append(warnings, issue.Code+" "+issue.Path)
+		}
+	}
+	return errs, warnings
+}
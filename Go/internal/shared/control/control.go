@@ -0,0 +1,253 @@
+// Package control carries operator commands to runs in progress.
+//
+// The worker manager records the desired state of a run in the Redis hash
+// run:{id}:control and the worker executing the run checks it before each
+// node. Commands therefore take effect between nodes: a node already
+// executing always completes.
+//
+//   - cancel: the run stops before its next node with the cancelled status
+//   - pause: the run stops before its next node until resumed; it is
+//     suspended off the worker (see package waits) and pushed again on resume
+//   - resume: a paused run goes on
+//   - step: a paused run executes one more node, then pauses again
+//
+// Debug runs also pause by themselves before their breakpoint nodes (see
+// Gate.Break) and stay on their worker while paused so that steps are
+// immediate, for at most MaxDebugPause before being suspended like any paused
+// run; "continue" and "abort" are accepted as aliases of resume and cancel
+// for them.
+package control
+
+import (
+	"XKA/pkg/RedisClient"
+	"XKA/pkg/logger"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TTL is how long the control state of a run stays in Redis.
+const TTL = 24 * time.Hour
+
+// PollInterval is how often a paused run checks for new commands.
+const PollInterval = 250 * time.Millisecond
+
+// MaxDebugPause is how long a paused debug run stays on its worker before it
+// is suspended off the worker.
+const MaxDebugPause = 1 * time.Hour
+
+// RefreshInterval is how often a run paused on its worker extends the TTL of
+// its control state.
+const RefreshInterval = 1 * time.Minute
+
+// Action is a command sent to a run.
+type Action string
+
+const (
+	ActionCancel Action = "cancel"
+	ActionPause  Action = "pause"
+	ActionResume Action = "resume"
+	ActionStep   Action = "step"
+)
+
+// Actions lists every supported command.
+var Actions = []Action{ActionCancel, ActionPause, ActionResume, ActionStep}
+
+// aliases maps the debugger command names to their action.
+var aliases = map[string]Action{
+	"continue": ActionResume,
+	"abort":    ActionCancel,
+}
+
+// ParseAction reads a command name (or one of the aliases continue and abort).
+func ParseAction(raw string) (Action, error) {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	if action, ok := aliases[name]; ok {
+		return action, nil
+	}
+
+	action := Action(name)
+	for _, known := range Actions {
+		if action == known {
+			return action, nil
+		}
+	}
+	return "", fmt.Errorf("unknown action %q (expected cancel, pause, resume, step, continue or abort)", raw)
+}
+
+// Key returns the Redis hash holding the control state of a run.
+func Key(runID string) string {
+	return "run:" + runID + ":control"
+}
+
+// Hash fields of the control state.
+const (
+	fieldState = "state" // running, paused or cancelled
+	fieldSteps = "steps" // Number of step commands received
+)
+
+const (
+	stateRunning   = "running"
+	statePaused    = "paused"
+	stateCancelled = "cancelled"
+)
+
+// State is the control state of a run.
+type State struct {
+	Paused    bool  `json:"paused"`
+	Cancelled bool  `json:"cancelled"`
+	Steps     int64 `json:"steps"` // Step commands received so far
+}
+
+// Send records a command for a run.
+func Send(client *RedisClient.Client, runID string, action Action) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+
+	key := Key(runID)
+	var err error
+	switch action {
+	case ActionCancel:
+		err = client.HSet(key, fieldState, stateCancelled)
+	case ActionPause:
+		err = client.HSet(key, fieldState, statePaused)
+	case ActionResume:
+		err = client.HSet(key, fieldState, stateRunning)
+	case ActionStep:
+		// A step leaves the run paused once the next node is done
+		if err = client.HSet(key, fieldState, statePaused); err == nil {
+			_, err = client.HIncrBy(key, fieldSteps, 1)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+	if err != nil {
+		return err
+	}
+	return client.SetExpire(key, TTL)
+}
+
+// StepSuspended records a single step for a paused run that is suspended off
+// its worker. The run is pushed again and picked up by a new gate, which has
+// consumed no step yet: the step count restarts at one so exactly one node
+// executes before the run pauses again.
+func StepSuspended(client *RedisClient.Client, runID string) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+
+	key := Key(runID)
+	if err := client.HSet(key, fieldState, statePaused); err != nil {
+		return err
+	}
+	if err := client.HSet(key, fieldSteps, "1"); err != nil {
+		return err
+	}
+	return client.SetExpire(key, TTL)
+}
+
+// Get returns the control state of a run. A run without commands is running.
+func Get(client *RedisClient.Client, runID string) (State, error) {
+	fields, err := client.HGetAll(Key(runID))
+	if err != nil {
+		return State{}, err
+	}
+
+	steps, _ := strconv.ParseInt(fields[fieldSteps], 10, 64)
+	return State{
+		Paused:    fields[fieldState] == statePaused,
+		Cancelled: fields[fieldState] == stateCancelled,
+		Steps:     steps,
+	}, nil
+}
+
+// Decision tells the runner what to do with its next node.
+type Decision int
+
+const (
+	Continue Decision = iota // Execute the node
+	Pause                    // Wait and check again
+	Cancel                   // Stop the run
+)
+
+// Gate is checked by the runner before each node of a run.
+// A nil gate always lets the run continue. A gate is not safe for concurrent use.
+type Gate struct {
+	client *RedisClient.Client
+	runID  string
+	steps  int64 // Step commands already consumed
+}
+
+// NewGate returns the gate of a run.
+func NewGate(client *RedisClient.Client, runID string) *Gate {
+	return &Gate{client: client, runID: runID}
+}
+
+// Next reads the control state of the run and decides on its next node.
+// Control errors never block a run: the node is executed.
+func (g *Gate) Next() Decision {
+	if g == nil || g.client == nil || g.runID == "" {
+		return Continue
+	}
+
+	state, err := Get(g.client, g.runID)
+	if err != nil {
+		logger.Log.Warn("Failed to read run control state", zap.String("run_id", g.runID), zap.Error(err))
+		return Continue
+	}
+	return g.decide(state)
+}
+
+// decide applies a control state to the next node. Each step command received
+// since the last one consumed lets a paused run execute one node.
+func (g *Gate) decide(state State) Decision {
+	switch {
+	case state.Cancelled:
+		return Cancel
+	case !state.Paused:
+		return Continue
+	case state.Steps > g.steps:
+		g.steps++
+		return Continue
+	default:
+		return Pause
+	}
+}
+
+// Break pauses the run by itself, as if a pause command had been received.
+// Step commands received before the break are discarded so the run waits for
+// a new command. Returns false if the run could not be paused.
+func (g *Gate) Break() bool {
+	if g == nil || g.client == nil || g.runID == "" {
+		return false
+	}
+
+	state, err := Get(g.client, g.runID)
+	if err == nil && state.Cancelled {
+		return true // The cancellation is picked up by Next
+	}
+	if err == nil {
+		err = Send(g.client, g.runID, ActionPause)
+	}
+	if err != nil {
+		logger.Log.Warn("Failed to pause run at breakpoint", zap.String("run_id", g.runID), zap.Error(err))
+		return false
+	}
+	g.steps = state.Steps
+	return true
+}
+
+// Refresh extends the TTL of the control state of a run paused on its worker,
+// so that the pause outlives TTL.
+func (g *Gate) Refresh() {
+	if g == nil || g.client == nil || g.runID == "" {
+		return
+	}
+	if err := g.client.SetExpire(Key(g.runID), TTL); err != nil {
+		logger.Log.Warn("Failed to refresh run control state", zap.String("run_id", g.runID), zap.Error(err))
+	}
+}
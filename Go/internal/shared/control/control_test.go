@@ -0,0 +1,74 @@
+package control
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Action
+		wantErr bool
+	}{
+		{"cancel", ActionCancel, false},
+		{"pause", ActionPause, false},
+		{"resume", ActionResume, false},
+		{"step", ActionStep, false},
+		{" Step ", ActionStep, false},
+		{"continue", ActionResume, false},
+		{"abort", ActionCancel, false},
+		{"", "", true},
+		{"stop", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAction(tt.raw)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseAction(%q) = %q, %v, want %q (error: %v)", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGateDecide(t *testing.T) {
+	running := State{}
+	paused := func(steps int64) State { return State{Paused: true, Steps: steps} }
+
+	tests := []struct {
+		name     string
+		consumed int64   // Steps already consumed by the gate
+		states   []State // Control state read before each node
+		want     []Decision
+	}{
+		{"running", 0, []State{running, running}, []Decision{Continue, Continue}},
+		{"cancelled", 0, []State{{Cancelled: true}}, []Decision{Cancel}},
+		{"cancel wins over a pending step", 0, []State{{Cancelled: true, Paused: true, Steps: 1}}, []Decision{Cancel}},
+		{"paused", 0, []State{paused(0), paused(0)}, []Decision{Pause, Pause}},
+		{"one step", 0, []State{paused(1), paused(1), paused(1)}, []Decision{Continue, Pause, Pause}},
+		{"steps received while paused", 0, []State{paused(0), paused(2), paused(2), paused(2)}, []Decision{Pause, Continue, Continue, Pause}},
+		{"resumed after steps", 0, []State{paused(1), paused(1), running}, []Decision{Continue, Pause, Continue}},
+		{"steps before a break are discarded", 3, []State{paused(3), paused(4), paused(4)}, []Decision{Pause, Continue, Pause}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Gate{steps: tt.consumed}
+			got := make([]Decision, 0, len(tt.states))
+			for _, state := range tt.states {
+				got = append(got, g.decide(state))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decisions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilGate(t *testing.T) {
+	var g *Gate
+	if got := g.Next(); got != Continue {
+		t.Errorf("Next() = %v, want Continue", got)
+	}
+	if g.Break() {
+		t.Error("Break() = true, want false")
+	}
+	g.Refresh() // Must not panic
+}
@@ -0,0 +1,86 @@
+package nodetypes
+
+// Built-in node type names.
+const (
+	ManualStartNode    = "manualStartNode"
+	CronTriggerNode    = "cronTriggerNode"
+	WebhookTriggerNode = "webhookTriggerNode"
+	EventTriggerNode   = "eventTriggerNode"
+	HttpRequestNode    = "httpRequestNode"
+	WaitingNode        = "waitingNode"
+	ApprovalNode       = "approvalNode"
+	WaitForEventNode   = "waitForEventNode"
+)
+
+// Branches of the nodes suspending a run.
+const (
+	BranchTimeout  = "timeout"  // No event arrived before the node's timeout
+	BranchRejected = "rejected" // The approval was denied
+)
+
+func init() {
+	// Triggers
+	Register(Definition{
+		Type:        ManualStartNode,
+		DisplayName: "Manual Start",
+		Description: "Starts the workflow on demand",
+		Trigger:     TriggerManual,
+		ConfigType:  ManualStartConfig{},
+	})
+	Register(Definition{
+		Type:        CronTriggerNode,
+		DisplayName: "Schedule",
+		Description: "Starts the workflow on a cron schedule",
+		Trigger:     TriggerCron,
+		ConfigType:  CronTriggerConfig{},
+	})
+	Register(Definition{
+		Type:        WebhookTriggerNode,
+		DisplayName: "Webhook",
+		Description: "Starts the workflow on an HTTP request to /hooks/{path}",
+		Trigger:     TriggerWebhook,
+		ConfigType:  WebhookTriggerConfig{},
+	})
+	Register(Definition{
+		Type:        EventTriggerNode,
+		DisplayName: "Event",
+		Description: "Starts the workflow when an event is published",
+		Trigger:     TriggerEvent,
+		ConfigType:  EventTriggerConfig{},
+	})
+
+	// Actions
+	Register(Definition{
+		Type:        HttpRequestNode,
+		DisplayName: "HTTP Request",
+		Description: "Sends an HTTP request",
+		ConfigType:  HttpRequestConfig{},
+	})
+	Register(Definition{
+		Type:        WaitingNode,
+		DisplayName: "Wait",
+		Category:    CategoryFlow,
+		Description: "Waits for a fixed duration",
+		ConfigType:  WaitingConfig{},
+	})
+
+	// Human in the loop
+	Register(Definition{
+		Type:        ApprovalNode,
+		DisplayName: "Approval",
+		Category:    CategoryHuman,
+		Description: "Suspends the run until a person approves or rejects it",
+		Branches:    []string{BranchRejected, BranchTimeout},
+		Suspends:    true,
+		ConfigType:  ApprovalConfig{},
+	})
+	Register(Definition{
+		Type:        WaitForEventNode,
+		DisplayName: "Wait for Event",
+		Category:    CategoryHuman,
+		Description: "Suspends the run until an external event arrives",
+		Branches:    []string{BranchTimeout},
+		Suspends:    true,
+		ConfigType:  WaitForEventConfig{},
+	})
+}
@@ -0,0 +1,58 @@
+package nodetypes
+
+import "time"
+
+// Typed configurations of the built-in node types, decoded from node data with
+// schema.Decode. Their schemas (served to the editor and checked at parse time)
+// are generated from the struct tags. Node data also carries editor fields
+// (label, pinnedData, breakpoint...), which are ignored.
+
+// ManualStartConfig configures a manualStartNode.
+type ManualStartConfig struct {
+	InputSchema map[string]interface{} `json:"inputSchema" description:"JSON Schema of the run input"`
+}
+
+// CronTriggerConfig configures a cronTriggerNode.
+type CronTriggerConfig struct {
+	Expression string `json:"expression" schema:"required,minLength=1" description:"Cron expression (5 fields, or 6 with seconds)"`
+	Timezone   string `json:"timezone" description:"IANA time zone the expression is evaluated in"`
+	CatchUp    string `json:"catchUp" schema:"enum=skip|latest|all" description:"Policy for ticks missed while the scheduler was down"`
+}
+
+// WebhookTriggerConfig configures a webhookTriggerNode.
+type WebhookTriggerConfig struct {
+	Path    string        `json:"path" schema:"required,pattern=^/*[A-Za-z0-9._~-]+(/[A-Za-z0-9._~-]+)*/*$" description:"Path under /hooks/"`
+	Methods []string      `json:"methods" schema:"default=POST" description:"Accepted HTTP methods"`
+	Mode    string        `json:"mode" schema:"default=async,enum=async|sync" description:"Answer at once or with the run result"`
+	Timeout time.Duration `json:"timeout" schema:"min=1,max=25000" description:"Wait for the run result in sync mode"`
+}
+
+// EventTriggerConfig configures an eventTriggerNode.
+type EventTriggerConfig struct {
+	Event string `json:"event" schema:"required,pattern=^[A-Za-z0-9._:-]+$" description:"Name of the event starting runs"`
+}
+
+// HttpRequestConfig configures an httpRequestNode.
+type HttpRequestConfig struct {
+	URL    string `json:"url" schema:"required,format=uri" description:"Requested URL"`
+	Method string `json:"method" schema:"default=GET,enum=GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS" description:"HTTP method"`
+}
+
+// WaitingConfig configures a waitingNode.
+type WaitingConfig struct {
+	Duration time.Duration `json:"duration" schema:"default=1000,min=1" description:"Time to wait: a duration (\"5s\") or milliseconds"`
+}
+
+// ApprovalConfig configures an approvalNode.
+type ApprovalConfig struct {
+	Message        string        `json:"message" description:"Shown to the approver"`
+	CorrelationKey string        `json:"correlationKey" description:"Key the decision must carry"`
+	Timeout        time.Duration `json:"timeout" schema:"min=1" description:"Time before taking the timeout branch"`
+}
+
+// WaitForEventConfig configures a waitForEventNode.
+type WaitForEventConfig struct {
+	Event          string        `json:"event" description:"Name of the awaited event"`
+	CorrelationKey string        `json:"correlationKey" description:"Key the event must carry"`
+	Timeout        time.Duration `json:"timeout" schema:"min=1" description:"Time before taking the timeout branch"`
+}
@@ -0,0 +1,197 @@
+// Package nodetypes is the registry of the node types known to the platform.
+// It is shared by the worker manager (validation, activation) and the workers
+// (execution), so both sides agree on what each node type is.
+package nodetypes
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"XKA/internal/shared/schema"
+)
+
+// TriggerKind describes how a trigger node starts runs.
+// Regular (non-trigger) node types have an empty kind.
+type TriggerKind string
+
+const (
+	TriggerManual  TriggerKind = "manual"  // Started on demand through the API
+	TriggerCron    TriggerKind = "cron"    // Started by the worker manager cron scheduler
+	TriggerWebhook TriggerKind = "webhook" // Started by an HTTP request on /hooks/{path}
+	TriggerEvent   TriggerKind = "event"   // Started by an event published through the API
+)
+
+// Category groups node types in the editor palette.
+type Category string
+
+const (
+	CategoryTrigger Category = "trigger" // Starts runs
+	CategoryAction  Category = "action"  // Acts on the outside world
+	CategoryFlow    Category = "flow"    // Controls the progress of the run
+	CategoryHuman   Category = "human"   // Waits for a person or an external system
+)
+
+// Handle IDs of the default input and output of a node.
+// Edges without a sourceHandle leave from the default output.
+const (
+	InputHandle   = "in"
+	DefaultHandle = "default"
+)
+
+// Handle is a connection point of a node in the editor.
+type Handle struct {
+	ID    string `json:"id"`    // Edge sourceHandle / targetHandle
+	Label string `json:"label"` // Shown next to the handle
+}
+
+// Definition declares a node type.
+type Definition struct {
+	Type        string         `json:"type"`                  // Node type name as used in workflow payloads
+	DisplayName string         `json:"displayName"`           // Name shown in the editor, the type when empty
+	Category    Category       `json:"category"`              // Palette group, derived from the trigger kind when empty
+	Description string         `json:"description,omitempty"` // One line description shown in the editor
+	Version     int            `json:"version"`               // Bumped on incompatible configuration changes, 1 when unset
+	Trigger     TriggerKind    `json:"trigger,omitempty"`     // Trigger kind, empty for regular nodes
+	Branches    []string       `json:"branches,omitempty"`    // Named outputs taken on specific outcomes, besides the default one
+	Suspends    bool           `json:"suspends,omitempty"`    // Nodes of this type suspend the run until an external event
+	Config      *schema.Schema `json:"config,omitempty"`      // JSON Schema of the node data, nil when any data is accepted
+	ConfigType  interface{}    `json:"-"`                     // Typed configuration struct (zero value); Config is generated from it
+	Inputs      []Handle       `json:"inputs"`                // Incoming handles, derived on registration
+	Outputs     []Handle       `json:"outputs"`               // Outgoing handles, derived on registration
+}
+
+// IsTrigger reports whether nodes of this type start runs.
+func (d Definition) IsTrigger() bool {
+	return d.Trigger != ""
+}
+
+// ValidateConfig checks node data against the configuration of the type.
+// Typed configurations are fully decoded, so values given as strings are
+// checked like the worker will read them.
+func (d Definition) ValidateConfig(data map[string]interface{}) schema.Errors {
+	if d.ConfigType != nil {
+		config := reflect.New(reflect.TypeOf(d.ConfigType))
+		return schema.Decode(data, config.Interface())
+	}
+	if d.Config == nil {
+		return nil
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return d.Config.Validate(data)
+}
+
+// HasBranch reports whether the node type declares the named output.
+func (d Definition) HasBranch(name string) bool {
+	for _, branch := range d.Branches {
+		if branch == name {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu          sync.RWMutex
+	definitions = make(map[string]Definition)
+)
+
+// Register adds a node type to the registry.
+// Panics on an empty or duplicate type since this is a programming error.
+func Register(def Definition) {
+	if def.Type == "" {
+		panic("nodetypes: cannot register a node type without a name")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := definitions[def.Type]; exists {
+		panic(fmt.Sprintf("nodetypes: node type %s registered twice", def.Type))
+	}
+	fillDefaults(&def)
+	definitions[def.Type] = def
+}
+
+// fillDefaults completes the editor metadata and the configuration schema of a
+// definition being registered.
+// Triggers have no input; every node has the default output, then one per branch.
+func fillDefaults(def *Definition) {
+	if def.DisplayName == "" {
+		def.DisplayName = def.Type
+	}
+	if def.Category == "" {
+		def.Category = CategoryAction
+		if def.IsTrigger() {
+			def.Category = CategoryTrigger
+		}
+	}
+	if def.Config == nil && def.ConfigType != nil {
+		def.Config = schema.Reflect(def.ConfigType)
+	}
+	if def.Version == 0 {
+		def.Version = 1
+	}
+
+	def.Inputs = []Handle{}
+	if !def.IsTrigger() {
+		def.Inputs = append(def.Inputs, Handle{ID: InputHandle, Label: "Input"})
+	}
+	def.Outputs = []Handle{{ID: DefaultHandle, Label: "Output"}}
+	for _, branch := range def.Branches {
+		def.Outputs = append(def.Outputs, Handle{ID: branch, Label: strings.ToUpper(branch[:1]) + branch[1:]})
+	}
+}
+
+// Lookup returns the definition of a node type.
+func Lookup(nodeType string) (Definition, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	def, ok := definitions[nodeType]
+	return def, ok
+}
+
+// IsTrigger reports whether nodes of the given type are workflow entry points.
+func IsTrigger(nodeType string) bool {
+	def, ok := Lookup(nodeType)
+	return ok && def.IsTrigger()
+}
+
+// TriggerKindOf returns the trigger kind of a node type (empty for regular nodes).
+func TriggerKindOf(nodeType string) TriggerKind {
+	def, _ := Lookup(nodeType)
+	return def.Trigger
+}
+
+// TriggerTypes returns the names of every trigger node type, sorted.
+func TriggerTypes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	types := make([]string, 0, len(definitions))
+	for name, def := range definitions {
+		if def.IsTrigger() {
+			types = append(types, name)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// All returns every registered definition sorted by type.
+func All() []Definition {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	defs := make([]Definition, 0, len(definitions))
+	for _, def := range definitions {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Type < defs[j].Type })
+	return defs
+}
@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"XKA/pkg/RedisClient"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PausedKey is the Redis hash of paused queue names -> pause time (unix ms).
+// Workers do not pop from a paused queue; jobs keep accumulating on it until
+// it is resumed. Jobs already executing are not affected.
+const PausedKey = QueuePrefix + ":paused"
+
+// AllQueues pauses every execution queue at once.
+const AllQueues = "all"
+
+// PausedPollInterval is how long a worker waits before checking again when
+// every queue it pops from is paused.
+const PausedPollInterval = 1 * time.Second
+
+// QueueState describes an execution queue.
+type QueueState struct {
+	Name     string `json:"name"`               // Priority name
+	Key      string `json:"key"`                // Redis list
+	Length   int64  `json:"length"`             // Jobs waiting to be popped
+	Paused   bool   `json:"paused"`             // Paused by itself or through AllQueues
+	PausedAt int64  `json:"pausedAt,omitempty"` // Unix milliseconds
+}
+
+// ParseQueueName reads a queue name: AllQueues or a priority name.
+func ParseQueueName(raw string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	if name == AllQueues {
+		return name, nil
+	}
+	for _, p := range Priorities {
+		if name == p.String() {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown queue %q (expected all, high, normal or low)", raw)
+}
+
+// Pause stops the workers from popping from a queue (or from every queue).
+func Pause(client *RedisClient.Client, name string) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	return client.HSet(PausedKey, name, strconv.FormatInt(time.Now().UnixMilli(), 10))
+}
+
+// Resume lets the workers pop from a paused queue again.
+// Resuming AllQueues lifts the global pause only; queues paused by name stay paused.
+func Resume(client *RedisClient.Client, name string) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	_, err := client.HDel(PausedKey, name)
+	return err
+}
+
+// Paused returns the paused queue names with their pause time.
+func Paused(client *RedisClient.Client) (map[string]int64, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client not initialized")
+	}
+	fields, err := client.HGetAll(PausedKey)
+	if err != nil {
+		return nil, err
+	}
+	paused := make(map[string]int64, len(fields))
+	for name, raw := range fields {
+		at, _ := strconv.ParseInt(raw, 10, 64)
+		paused[name] = at
+	}
+	return paused, nil
+}
+
+// Poppable filters the queue keys of PopOrder down to the queues that are not paused.
+// Errors reading the pause state never stop the workers: every key is returned.
+func Poppable(client *RedisClient.Client, keys []string) []string {
+	paused, err := Paused(client)
+	if err != nil || len(paused) == 0 {
+		return keys
+	}
+	if _, ok := paused[AllQueues]; ok {
+		return nil
+	}
+
+	open := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := paused[strings.TrimPrefix(key, QueuePrefix+":")]; !ok {
+			open = append(open, key)
+		}
+	}
+	return open
+}
+
+// States returns the length and pause state of every execution queue, highest
+// priority first, and whether every queue is paused at once.
+func States(client *RedisClient.Client) ([]QueueState, bool, error) {
+	paused, err := Paused(client)
+	if err != nil {
+		return nil, false, err
+	}
+
+	globalAt, global := paused[AllQueues]
+	states := make([]QueueState, 0, len(Priorities))
+	for _, p := range Priorities {
+		length, err := client.LLen(p.QueueName())
+		if err != nil {
+			return nil, false, err
+		}
+		state := QueueState{Name: p.String(), Key: p.QueueName(), Length: length}
+		if at, ok := paused[p.String()]; ok {
+			state.Paused, state.PausedAt = true, at
+		} else if global {
+			state.Paused, state.PausedAt = true, globalAt
+		}
+		states = append(states, state)
+	}
+	return states, global, nil
+}
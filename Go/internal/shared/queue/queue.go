@@ -0,0 +1,342 @@
+// Package queue defines the Redis job queues shared by the worker manager and the workers.
+package queue
+
+import (
+	"XKA/internal/shared/nodetypes"
+	"XKA/pkg/RedisClient"
+	"XKA/pkg/ids"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueuePrefix is the common prefix of every execution queue key.
+const QueuePrefix = "workflows"
+
+// LegacyQueueName is the single list jobs were pushed on before priorities.
+// Its entries are bare serialized workflows; workers still drain it after every
+// priority queue so nothing pushed by an older manager is lost.
+// TODO: Remove once every deployment has drained it.
+const LegacyQueueName = QueuePrefix
+
+// ResultTTL is how long a run's final result stays available to waiting callers.
+const ResultTTL = 5 * time.Minute
+
+// ErrResultTimeout is returned by WaitResult when the run did not finish in time.
+var ErrResultTimeout = errors.New("timed out waiting for job result")
+
+// Priority is the scheduling class of a queued job.
+// Higher values are drained first by the workers.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// Priorities lists every priority from highest to lowest.
+var Priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// popWeights controls how often each priority is served first.
+// Over a full cycle a low priority queue with a backlog is guaranteed
+// at least one pop, which prevents starvation under sustained high load.
+var popWeights = map[Priority]int{
+	PriorityHigh:   6,
+	PriorityNormal: 3,
+	PriorityLow:    1,
+}
+
+// Job kinds.
+const (
+	KindRun  = ""     // Run of a workflow (default)
+	KindNode = "node" // Isolated execution of a single node, for testing (see Job.Node)
+)
+
+// Run modes.
+const (
+	ModeProduction  = "production"  // Every node is executed (default)
+	ModeDevelopment = "development" // Pinned nodes are not executed, their pinned output is used
+	ModeDebug       = "debug"       // Development run pausing before its breakpoint nodes
+)
+
+// ParseMode reads a submitted run mode. A nil or empty value yields ModeProduction.
+func ParseMode(raw interface{}) (string, error) {
+	if raw == nil {
+		return ModeProduction, nil
+	}
+	name, ok := raw.(string)
+	if !ok {
+		return ModeProduction, fmt.Errorf("mode must be a string")
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(name)); mode {
+	case "", ModeProduction:
+		return ModeProduction, nil
+	case ModeDevelopment, ModeDebug:
+		return mode, nil
+	default:
+		return ModeProduction, fmt.Errorf("invalid mode %q: expected production, development or debug", name)
+	}
+}
+
+// Job is the envelope pushed on the execution queues.
+type Job struct {
+	Kind          string                     `json:"kind,omitempty"`          // Job kind, empty for a workflow run
+	RunID         string                     `json:"runId"`                   // Unique execution identifier
+	WorkflowID    string                     `json:"workflowId"`              // Executed workflow identifier
+	Revision      int                        `json:"revision,omitempty"`      // Stored workflow revision (0 for ad-hoc submissions)
+	Priority      Priority                   `json:"priority"`                // Scheduling class
+	Mode          string                     `json:"mode,omitempty"`          // Run mode, production when empty
+	EnqueuedAt    int64                      `json:"enqueuedAt"`              // Unix timestamp of the push
+	Workflow      json.RawMessage            `json:"workflow,omitempty"`      // Serialized builder.Workflow
+	Trigger       string                     `json:"trigger,omitempty"`       // Trigger kind that started the run (manual, cron...)
+	TriggerNodeID string                     `json:"triggerNodeId,omitempty"` // Start node to run from (defaults to the first start node)
+	Input         map[string]interface{}     `json:"input,omitempty"`         // Data provided by the trigger
+	RetryOf       string                     `json:"retryOf,omitempty"`       // Run retried by this one
+	RetryFrom     string                     `json:"retryFrom,omitempty"`     // Node re-executed first by the retry
+	Reuse         map[string]json.RawMessage `json:"reuse,omitempty"`         // Recorded responses of the upstream nodes, by node ID
+	Node          json.RawMessage            `json:"node,omitempty"`          // Serialized builder.Node of a node job
+	Breakpoints   []string                   `json:"breakpoints,omitempty"`   // Extra breakpoint nodes of a debug run
+	Restore       []json.RawMessage          `json:"restore,omitempty"`       // Responses of the nodes executed before the run was suspended, in order
+	Resume        *Resolution                `json:"resume,omitempty"`        // Outcome of the wait a suspended run resumes from
+	Resumed       bool                       `json:"resumed,omitempty"`       // Job continues a suspended or paused run
+}
+
+// Wait outcomes.
+const (
+	OutcomeEvent   = "event"   // The awaited event was delivered
+	OutcomeTimeout = "timeout" // Nothing arrived before the deadline
+)
+
+// Resolution ends the wait of a suspended run (see package waits).
+type Resolution struct {
+	NodeID     string          `json:"nodeId"`            // Node the run is suspended at
+	Outcome    string          `json:"outcome"`           // OutcomeEvent or OutcomeTimeout
+	Payload    json.RawMessage `json:"payload,omitempty"` // Delivered event payload
+	ReceivedAt int64           `json:"receivedAt"`        // Unix milliseconds
+}
+
+// String returns the canonical name of the priority.
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// QueueName returns the Redis list holding jobs of this priority.
+func (p Priority) QueueName() string {
+	return QueuePrefix + ":" + p.String()
+}
+
+// MarshalJSON encodes the priority by name.
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON accepts the same representations as ParsePriority.
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := ParsePriority(raw)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// ParsePriority converts a submitted priority into a Priority.
+// Accepts "high", "normal", "low" or a level from 0 (lowest) to 9 (highest),
+// either as a number or a numeric string. A nil value yields PriorityNormal.
+func ParsePriority(raw interface{}) (Priority, error) {
+	switch v := raw.(type) {
+	case nil:
+		return PriorityNormal, nil
+	case Priority:
+		return v, nil
+	case float64:
+		if v != float64(int(v)) {
+			return PriorityNormal, fmt.Errorf("priority must be an integer between 0 and 9")
+		}
+		return priorityFromLevel(int(v))
+	case int:
+		return priorityFromLevel(v)
+	case string:
+		name := strings.ToLower(strings.TrimSpace(v))
+		switch name {
+		case "", "normal":
+			return PriorityNormal, nil
+		case "high":
+			return PriorityHigh, nil
+		case "low":
+			return PriorityLow, nil
+		}
+		level, err := strconv.Atoi(name)
+		if err != nil {
+			return PriorityNormal, fmt.Errorf("invalid priority %q: expected high, normal, low or 0-9", v)
+		}
+		return priorityFromLevel(level)
+	default:
+		return PriorityNormal, fmt.Errorf("priority must be a string or a number")
+	}
+}
+
+// priorityFromLevel maps a 0-9 level onto the three priority classes.
+func priorityFromLevel(level int) (Priority, error) {
+	switch {
+	case level < 0 || level > 9:
+		return PriorityNormal, fmt.Errorf("priority level %d out of range 0-9", level)
+	case level >= 7:
+		return PriorityHigh, nil
+	case level >= 3:
+		return PriorityNormal, nil
+	default:
+		return PriorityLow, nil
+	}
+}
+
+// PopOrder returns the queue keys to pass to BRPOP for the given pop counter.
+// Queues are normally checked from highest to lowest priority, but the counter
+// walks a weighted round-robin cycle that periodically puts lower priorities
+// first so they keep draining while higher priority work is flowing.
+// The legacy queue always comes last.
+func PopOrder(tick uint64) []string {
+	total := 0
+	for _, p := range Priorities {
+		total += popWeights[p]
+	}
+
+	slot := int(tick % uint64(total))
+	lead := Priorities[0]
+	for _, p := range Priorities {
+		if slot < popWeights[p] {
+			lead = p
+			break
+		}
+		slot -= popWeights[p]
+	}
+
+	keys := make([]string, 0, len(Priorities)+1)
+	keys = append(keys, lead.QueueName())
+	for _, p := range Priorities {
+		if p != lead {
+			keys = append(keys, p.QueueName())
+		}
+	}
+	return append(keys, LegacyQueueName)
+}
+
+// Enqueue pushes a job on the queue matching its priority.
+func Enqueue(client *RedisClient.Client, job *Job) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	if job == nil {
+		return fmt.Errorf("job cannot be nil")
+	}
+	if job.EnqueuedAt == 0 {
+		job.EnqueuedAt = time.Now().Unix()
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if _, err := client.LPush(job.Priority.QueueName(), string(data)); err != nil {
+		return fmt.Errorf("failed to push run %s: %w", job.RunID, err)
+	}
+	return nil
+}
+
+// DecodeEntry parses an entry popped from the given queue key into a Job.
+// Entries of the legacy queue become low priority manual runs.
+func DecodeEntry(key, raw string) (*Job, error) {
+	if key != LegacyQueueName {
+		return DecodeJob(raw)
+	}
+
+	var workflow struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(raw), &workflow); err != nil {
+		return nil, fmt.Errorf("failed to decode legacy job: %w", err)
+	}
+	return &Job{
+		RunID:      ids.New("run"),
+		WorkflowID: workflow.ID,
+		Priority:   PriorityLow,
+		EnqueuedAt: time.Now().Unix(),
+		Workflow:   json.RawMessage(raw),
+		Trigger:    string(nodetypes.TriggerManual),
+	}, nil
+}
+
+// DecodeJob parses a raw queue entry into a Job.
+func DecodeJob(raw string) (*Job, error) {
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+	switch job.Kind {
+	case KindRun:
+		if len(job.Workflow) == 0 {
+			return nil, fmt.Errorf("run %s has no workflow", job.RunID)
+		}
+	case KindNode:
+		if len(job.Node) == 0 {
+			return nil, fmt.Errorf("node job %s has no node", job.RunID)
+		}
+	default:
+		return nil, fmt.Errorf("job %s has unknown kind %q", job.RunID, job.Kind)
+	}
+	return &job, nil
+}
+
+// ResultKey returns the Redis list receiving the final result of a run.
+func ResultKey(runID string) string {
+	return "run:" + runID + ":final"
+}
+
+// PublishResult makes the final result of a run available to WaitResult callers.
+func PublishResult(client *RedisClient.Client, runID string, result []byte) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+
+	key := ResultKey(runID)
+	if _, err := client.LPush(key, string(result)); err != nil {
+		return err
+	}
+	return client.SetExpire(key, ResultTTL)
+}
+
+// WaitResult blocks until the final result of a run is published, the timeout
+// elapses or ctx is done. The wait holds a connection of the blocking client
+// (RedisClient.GetBlockingClient) so it never starves regular commands.
+func WaitResult(ctx context.Context, runID string, timeout time.Duration) (string, error) {
+	client := RedisClient.GetBlockingClient()
+	if client == nil {
+		return "", fmt.Errorf("redis client not initialized")
+	}
+
+	entry, err := client.WithContext(ctx).BRPop(timeout, ResultKey(runID))
+	if err != nil {
+		if errors.Is(err, RedisClient.ErrTimeout) {
+			return "", ErrResultTimeout
+		}
+		return "", err
+	}
+	return entry[1], nil
+}
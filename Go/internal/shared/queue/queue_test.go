@@ -0,0 +1,137 @@
+package queue
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     interface{}
+		want    Priority
+		wantErr bool
+	}{
+		{"nil", nil, PriorityNormal, false},
+		{"empty", "", PriorityNormal, false},
+		{"high", "high", PriorityHigh, false},
+		{"normal", "normal", PriorityNormal, false},
+		{"low", "low", PriorityLow, false},
+		{"case and spaces", " HIGH ", PriorityHigh, false},
+		{"lowest level", 0.0, PriorityLow, false},
+		{"low level", 2.0, PriorityLow, false},
+		{"lowest normal level", 3.0, PriorityNormal, false},
+		{"highest normal level", 6.0, PriorityNormal, false},
+		{"high level", 7.0, PriorityHigh, false},
+		{"highest level", 9.0, PriorityHigh, false},
+		{"numeric string", "8", PriorityHigh, false},
+		{"int", 1, PriorityLow, false},
+		{"priority", PriorityHigh, PriorityHigh, false},
+		{"level out of range", 10.0, PriorityNormal, true},
+		{"negative level", -1.0, PriorityNormal, true},
+		{"fractional level", 4.5, PriorityNormal, true},
+		{"unknown name", "urgent", PriorityNormal, true},
+		{"wrong type", true, PriorityNormal, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePriority(tt.raw)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("ParsePriority(%v) = %s, %v, want %s (error: %v)", tt.raw, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPriorityJSON(t *testing.T) {
+	data, err := json.Marshal(PriorityHigh)
+	if err != nil || string(data) != `"high"` {
+		t.Fatalf("Marshal(PriorityHigh) = %s, %v, want \"high\"", data, err)
+	}
+
+	var p Priority
+	if err := json.Unmarshal([]byte(`1`), &p); err != nil || p != PriorityLow {
+		t.Errorf("Unmarshal(1) = %s, %v, want low", p, err)
+	}
+	if err := json.Unmarshal([]byte(`"fast"`), &p); err == nil {
+		t.Error("Unmarshal(\"fast\") succeeded, want an error")
+	}
+}
+
+func TestPopOrder(t *testing.T) {
+	high, normal, low := PriorityHigh.QueueName(), PriorityNormal.QueueName(), PriorityLow.QueueName()
+
+	tests := []struct {
+		tick uint64
+		want []string
+	}{
+		{0, []string{high, normal, low, LegacyQueueName}},
+		{5, []string{high, normal, low, LegacyQueueName}},
+		{6, []string{normal, high, low, LegacyQueueName}},
+		{8, []string{normal, high, low, LegacyQueueName}},
+		{9, []string{low, high, normal, LegacyQueueName}},
+		{10, []string{high, normal, low, LegacyQueueName}}, // Next cycle
+	}
+	for _, tt := range tests {
+		if got := PopOrder(tt.tick); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("PopOrder(%d) = %v, want %v", tt.tick, got, tt.want)
+		}
+	}
+}
+
+func TestPopOrderFairness(t *testing.T) {
+	// With every queue backlogged, each pop serves the first key: over whole
+	// cycles the priorities are served 6:3:1
+	served := make(map[string]int)
+	for tick := uint64(0); tick < 100; tick++ {
+		served[PopOrder(tick)[0]]++
+	}
+
+	want := map[string]int{
+		PriorityHigh.QueueName():   60,
+		PriorityNormal.QueueName(): 30,
+		PriorityLow.QueueName():    10,
+	}
+	for key, count := range want {
+		if served[key] != count {
+			t.Errorf("%s served first %d times out of 100, want %d", key, served[key], count)
+		}
+	}
+}
+
+func TestPopOrderDrainsLegacyQueueLast(t *testing.T) {
+	for tick := uint64(0); tick < 10; tick++ {
+		keys := PopOrder(tick)
+		if len(keys) != len(Priorities)+1 || keys[len(keys)-1] != LegacyQueueName {
+			t.Errorf("PopOrder(%d) = %v, want the legacy queue last", tick, keys)
+		}
+	}
+}
+
+func TestDecodeEntry(t *testing.T) {
+	legacy := `{"id": "wf-1", "nodeMap": {}, "startNodeIds": []}`
+	job, err := DecodeEntry(LegacyQueueName, legacy)
+	if err != nil {
+		t.Fatalf("DecodeEntry() of a legacy entry error = %v", err)
+	}
+	if job.WorkflowID != "wf-1" || job.Priority != PriorityLow || job.Kind != KindRun {
+		t.Errorf("DecodeEntry() = %+v, want a low priority run of wf-1", job)
+	}
+	if !strings.HasPrefix(job.RunID, "run_") || string(job.Workflow) != legacy {
+		t.Errorf("DecodeEntry() = %+v, want a new run of the legacy workflow", job)
+	}
+
+	if _, err := DecodeEntry(LegacyQueueName, `{"id": `); err == nil {
+		t.Error("DecodeEntry() of an invalid legacy entry succeeded, want an error")
+	}
+
+	raw, _ := json.Marshal(&Job{RunID: "run-1", WorkflowID: "wf-1", Priority: PriorityHigh, Workflow: json.RawMessage(`{}`)})
+	job, err = DecodeEntry(PriorityHigh.QueueName(), string(raw))
+	if err != nil {
+		t.Fatalf("DecodeEntry() error = %v", err)
+	}
+	if job.RunID != "run-1" || job.Priority != PriorityHigh {
+		t.Errorf("DecodeEntry() = %+v, want the queued job", job)
+	}
+}
@@ -0,0 +1,345 @@
+// Package runlog records the progress of a run as append-only events.
+//
+// Each run has two Redis keys:
+//   - run:{id}:events, a stream of events (run started, node started,
+//     node log, node finished, run paused/resumed, run waiting, run finished)
+//     that can be replayed or followed live
+//   - run:{id}:state, a hash holding the compacted current state of the run:
+//     the run header under "meta", one field per executed node and, while the
+//     run is paused, the pending node under "paused"
+//
+// A node's output is written once when it finishes, so the size of a run's
+// log grows linearly with the number of nodes.
+package runlog
+
+import (
+	"XKA/pkg/RedisClient"
+	"XKA/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TTL is how long the events and state of a run stay in Redis.
+const TTL = 24 * time.Hour
+
+// MaxEvents bounds the length of a run's event stream.
+const MaxEvents = 10000
+
+// EventType identifies what happened in a run.
+type EventType string
+
+const (
+	RunStarted   EventType = "run.started"
+	NodeStarted  EventType = "node.started"
+	NodeLog      EventType = "node.log"
+	NodeFinished EventType = "node.finished"
+	RunPaused    EventType = "run.paused"
+	RunResumed   EventType = "run.resumed"
+	RunWaiting   EventType = "run.waiting"
+	RunFinished  EventType = "run.finished"
+)
+
+// Event is an entry of a run's event log.
+type Event struct {
+	ID        string          `json:"id,omitempty"` // Stream entry ID, assigned by Redis
+	Type      EventType       `json:"type"`
+	RunID     string          `json:"runId"`
+	NodeID    string          `json:"nodeId,omitempty"`
+	NodeType  string          `json:"nodeType,omitempty"`
+	Position  int             `json:"position"`  // Execution order of the node within the run
+	Timestamp int64           `json:"timestamp"` // Unix milliseconds
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// EventsKey returns the Redis stream holding the events of a run.
+func EventsKey(runID string) string {
+	return "run:" + runID + ":events"
+}
+
+// StateKey returns the Redis hash holding the compacted state of a run.
+func StateKey(runID string) string {
+	return "run:" + runID + ":state"
+}
+
+const (
+	metaField       = "meta"
+	pausedField     = "paused"
+	nodeFieldPrefix = "node:"
+)
+
+func nodeField(position int) string {
+	return fmt.Sprintf("%s%04d", nodeFieldPrefix, position)
+}
+
+// Recorder appends the events of one run and keeps its state up to date.
+// Recording errors are logged and never fail the run.
+// A nil recorder records nothing. A recorder is not safe for concurrent use.
+type Recorder struct {
+	client *RedisClient.Client
+	runID  string
+
+	// Node currently executing, target of NodeLog
+	position int
+	nodeID   string
+	nodeType string
+}
+
+// NewRecorder returns a recorder for a run.
+func NewRecorder(client *RedisClient.Client, runID string) *Recorder {
+	return &Recorder{client: client, runID: runID}
+}
+
+// RunStarted records the start of the run. meta is the run header (without nodes).
+func (r *Recorder) RunStarted(meta interface{}) {
+	data, ok := r.marshal(meta)
+	if !ok {
+		return
+	}
+	r.setState(metaField, data)
+	r.append(&Event{Type: RunStarted, Data: data})
+}
+
+// NodeStarted records that a node began executing.
+func (r *Recorder) NodeStarted(position int, nodeID, nodeType string) {
+	now := time.Now()
+	data, ok := r.marshal(map[string]interface{}{
+		"nodeId":    nodeID,
+		"nodeType":  nodeType,
+		"status":    "running",
+		"timestamp": now.Unix(),
+	})
+	if !ok {
+		return
+	}
+	r.position, r.nodeID, r.nodeType = position, nodeID, nodeType
+	r.setState(nodeField(position), data)
+	r.append(&Event{Type: NodeStarted, NodeID: nodeID, NodeType: nodeType, Position: position})
+}
+
+// NodeLog records a log line of the node currently executing.
+// Logs are only streamed; the node's full logs are part of its finished response.
+func (r *Recorder) NodeLog(line string) {
+	data, ok := r.marshal(map[string]string{"message": line})
+	if !ok {
+		return
+	}
+	r.append(&Event{Type: NodeLog, NodeID: r.nodeID, NodeType: r.nodeType, Position: r.position, Data: data})
+}
+
+// NodeFinished records the response of a node, output included.
+func (r *Recorder) NodeFinished(position int, nodeID, nodeType string, response interface{}) {
+	data, ok := r.marshal(response)
+	if !ok {
+		return
+	}
+	r.setState(nodeField(position), data)
+	r.append(&Event{Type: NodeFinished, NodeID: nodeID, NodeType: nodeType, Position: position, Data: data})
+}
+
+// RunPaused records that the run is waiting before a node. details describes
+// the pause (reason, pending node inputs...); it is kept in the run state until
+// the run resumes.
+func (r *Recorder) RunPaused(position int, nodeID, nodeType string, details interface{}) {
+	data, ok := r.marshal(details)
+	if !ok {
+		return
+	}
+	r.setState(pausedField, data)
+	r.append(&Event{Type: RunPaused, NodeID: nodeID, NodeType: nodeType, Position: position, Data: data})
+}
+
+// RunResumed records that a paused run goes on with its pending node.
+func (r *Recorder) RunResumed(position int, nodeID, nodeType string) {
+	if !r.enabled() {
+		return
+	}
+	if _, err := r.client.HDel(StateKey(r.runID), pausedField); err != nil {
+		logger.Log.Warn("Failed to update run state", zap.String("run_id", r.runID), zap.Error(err))
+	}
+	r.append(&Event{Type: RunResumed, NodeID: nodeID, NodeType: nodeType, Position: position})
+}
+
+// RunWaiting records that the run is suspended at a node until an external
+// event arrives. meta is the run header; response is the waiting node's
+// response, describing what the run waits for.
+func (r *Recorder) RunWaiting(meta interface{}, position int, nodeID, nodeType string, response interface{}) {
+	header, ok := r.marshal(meta)
+	if !ok {
+		return
+	}
+	data, ok := r.marshal(response)
+	if !ok {
+		return
+	}
+	r.setState(metaField, header)
+	r.setState(nodeField(position), data)
+	r.append(&Event{Type: RunWaiting, NodeID: nodeID, NodeType: nodeType, Position: position, Data: data})
+}
+
+// WaitResolved records that a suspended run goes on with the node it waited at.
+// meta is the run header; details describes how the wait ended.
+func (r *Recorder) WaitResolved(meta interface{}, position int, nodeID, nodeType string, details interface{}) {
+	header, ok := r.marshal(meta)
+	if !ok {
+		return
+	}
+	data, ok := r.marshal(details)
+	if !ok {
+		return
+	}
+	r.setState(metaField, header)
+	r.append(&Event{Type: RunResumed, NodeID: nodeID, NodeType: nodeType, Position: position, Data: data})
+}
+
+// RunFinished records the outcome of the run. meta is the final run header (without nodes).
+func (r *Recorder) RunFinished(meta interface{}) {
+	data, ok := r.marshal(meta)
+	if !ok {
+		return
+	}
+	r.client.HDel(StateKey(r.runID), pausedField) // Cancelled while paused
+	r.setState(metaField, data)
+	r.append(&Event{Type: RunFinished, Data: data})
+}
+
+func (r *Recorder) enabled() bool {
+	return r != nil && r.client != nil && r.runID != ""
+}
+
+func (r *Recorder) marshal(v interface{}) (json.RawMessage, bool) {
+	if !r.enabled() {
+		return nil, false
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Log.Warn("Failed to marshal run event",
+			zap.String("run_id", r.runID),
+			zap.Error(err),
+		)
+		return nil, false
+	}
+	return data, true
+}
+
+func (r *Recorder) setState(field string, data json.RawMessage) {
+	key := StateKey(r.runID)
+	if err := r.client.HSet(key, field, string(data)); err != nil {
+		logger.Log.Warn("Failed to update run state", zap.String("run_id", r.runID), zap.Error(err))
+		return
+	}
+	r.client.SetExpire(key, TTL)
+}
+
+func (r *Recorder) append(event *Event) {
+	event.RunID = r.runID
+	event.Timestamp = time.Now().UnixMilli()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Log.Warn("Failed to marshal run event", zap.String("run_id", r.runID), zap.Error(err))
+		return
+	}
+
+	key := EventsKey(r.runID)
+	if _, err := r.client.XAdd(key, MaxEvents, map[string]interface{}{"event": string(data)}); err != nil {
+		logger.Log.Warn("Failed to append run event", zap.String("run_id", r.runID), zap.Error(err))
+		return
+	}
+	r.client.SetExpire(key, TTL)
+}
+
+// Events returns the events of a run recorded after the given event ID
+// (empty to read from the beginning), at most count of them (0 = all).
+func Events(client *RedisClient.Client, runID, afterID string, count int64) ([]Event, error) {
+	start := "-"
+	if afterID != "" {
+		start = "(" + afterID
+	}
+
+	entries, err := client.XRange(EventsKey(runID), start, "+", count)
+	if err != nil {
+		return nil, err
+	}
+	return decodeEntries(entries), nil
+}
+
+// Wait blocks until events are recorded after the given event ID ("0" to read
+// from the beginning) and returns them, at most count of them.
+// Returns RedisClient.ErrTimeout if nothing was recorded before the timeout.
+func Wait(client *RedisClient.Client, runID, afterID string, count int64, timeout time.Duration) ([]Event, error) {
+	entries, err := client.XRead(EventsKey(runID), afterID, count, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return decodeEntries(entries), nil
+}
+
+// decodeEntries converts stream entries to events, skipping malformed ones.
+func decodeEntries(entries []RedisClient.StreamEntry) []Event {
+	events := make([]Event, 0, len(entries))
+	for _, entry := range entries {
+		raw, _ := entry.Values["event"].(string)
+		var event Event
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			continue
+		}
+		event.ID = entry.ID
+		events = append(events, event)
+	}
+	return events
+}
+
+// Snapshot assembles the current state of a run: its header with the nodes
+// executed so far, in the same shape as the runner's execution result, plus
+// the pending node under "paused" while the run is paused.
+// Returns false if nothing was recorded for the run.
+func Snapshot(client *RedisClient.Client, runID string) (json.RawMessage, bool, error) {
+	fields, err := client.HGetAll(StateKey(runID))
+	if err != nil {
+		return nil, false, err
+	}
+
+	meta, ok := fields[metaField]
+	if !ok {
+		return nil, false, nil
+	}
+
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(meta), &state); err != nil {
+		return nil, false, fmt.Errorf("invalid state for run %s: %w", runID, err)
+	}
+
+	positions := make([]int, 0, len(fields)-1)
+	for field := range fields {
+		if position, err := strconv.Atoi(strings.TrimPrefix(field, nodeFieldPrefix)); err == nil && strings.HasPrefix(field, nodeFieldPrefix) {
+			positions = append(positions, position)
+		}
+	}
+	sort.Ints(positions)
+
+	nodes := make([]json.RawMessage, 0, len(positions))
+	for _, position := range positions {
+		nodes = append(nodes, json.RawMessage(fields[nodeField(position)]))
+	}
+
+	data, err := json.Marshal(nodes)
+	if err != nil {
+		return nil, false, err
+	}
+	state["nodes"] = data
+	if paused, ok := fields[pausedField]; ok {
+		state["paused"] = json.RawMessage(paused)
+	}
+
+	snapshot, err := json.Marshal(state)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal run state: %w", err)
+	}
+	return snapshot, true, nil
+}
@@ -0,0 +1,534 @@
+// Package runs keeps the record of every workflow execution.
+// Each run has its own ID, distinct from the workflow ID, so several runs of
+// the same workflow never share results. The worker manager creates the record
+// when a run is submitted and the worker updates it while executing.
+//
+// Records are persisted in the durable store (see package storage); Redis keeps
+// a copy of recent records as a hot cache. Live progress is read from the run
+// log (see package runlog).
+package runs
+
+import (
+	"XKA/internal/shared/queue"
+	"XKA/internal/shared/runlog"
+	"XKA/internal/shared/storage"
+	"XKA/pkg/RedisClient"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CacheTTL is how long run records stay in the Redis cache.
+const CacheTTL = 24 * time.Hour
+
+// Status is the lifecycle state of a run.
+type Status string
+
+const (
+	StatusScheduled Status = "scheduled" // Waiting for its runAt time
+	StatusQueued    Status = "queued"    // Pushed on an execution queue
+	StatusRunning   Status = "running"   // Picked up by a worker
+	StatusWaiting   Status = "waiting"   // Suspended until an external event, off any worker
+	StatusPaused    Status = "paused"    // Paused by an operator between two nodes, off any worker
+	StatusSuccess   Status = "success"   // Finished without error
+	StatusError     Status = "error"     // Finished with an error
+	StatusCancelled Status = "cancelled" // Cancelled before it started
+)
+
+// IsFinal reports whether the run will not change anymore.
+func (s Status) IsFinal() bool {
+	return s == StatusSuccess || s == StatusError || s == StatusCancelled
+}
+
+// Run is the summary of a workflow execution.
+// Times are unix milliseconds, zero when not reached yet.
+type Run struct {
+	RunID         string `json:"runId"`
+	WorkflowID    string `json:"workflowId"`
+	Revision      int    `json:"revision,omitempty"` // Workflow revision executed (0 for ad-hoc submissions)
+	Status        Status `json:"status"`
+	Trigger       string `json:"trigger,omitempty"`       // Trigger kind (manual, cron, webhook, event)
+	TriggerNodeID string `json:"triggerNodeId,omitempty"` // Trigger node the run started from
+	Priority      string `json:"priority,omitempty"`
+	CreatedAt     int64  `json:"createdAt"`
+	QueuedAt      int64  `json:"queuedAt,omitempty"`
+	StartedAt     int64  `json:"startedAt,omitempty"`
+	EndedAt       int64  `json:"endedAt,omitempty"`
+	DurationMs    int64  `json:"durationMs,omitempty"`
+	Error         string `json:"error,omitempty"`
+	Mode          string `json:"mode,omitempty"`      // production or development
+	RetryOf       string `json:"retryOf,omitempty"`   // Run retried by this one
+	RetryFrom     string `json:"retryFrom,omitempty"` // Node the retry re-executed from
+
+	Input map[string]interface{} `json:"input,omitempty"` // Validated run input
+	Graph json.RawMessage        `json:"-"`               // Graph of an ad-hoc submission, stored on creation only
+}
+
+// Key returns the Redis key holding a run record.
+func Key(runID string) string {
+	return "run:" + runID
+}
+
+// FromJob builds the record of a run about to be submitted.
+func FromJob(job *queue.Job, status Status) *Run {
+	mode := job.Mode
+	if mode == "" {
+		mode = queue.ModeProduction
+	}
+
+	return &Run{
+		RunID:         job.RunID,
+		WorkflowID:    job.WorkflowID,
+		Revision:      job.Revision,
+		Status:        status,
+		Trigger:       job.Trigger,
+		TriggerNodeID: job.TriggerNodeID,
+		Priority:      job.Priority.String(),
+		CreatedAt:     time.Now().UnixMilli(),
+		Mode:          mode,
+		RetryOf:       job.RetryOf,
+		RetryFrom:     job.RetryFrom,
+		Input:         job.Input,
+	}
+}
+
+// Create stores a new run.
+func Create(client *RedisClient.Client, run *Run) error {
+	return Save(client, run)
+}
+
+// Save persists a run record and refreshes its cached copy.
+func Save(client *RedisClient.Client, run *Run) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	if run == nil || run.RunID == "" {
+		return fmt.Errorf("run must have an ID")
+	}
+
+	store := storage.GetStore()
+	if store == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+	if err := store.SaveRun(context.Background(), run.toRecord()); err != nil {
+		return err
+	}
+	return cache(client, run)
+}
+
+// cache refreshes the cached copy of a run.
+func cache(client *RedisClient.Client, run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run: %w", err)
+	}
+	return client.Set(Key(run.RunID), string(data), CacheTTL)
+}
+
+// Get returns a run record, from the cache when possible.
+func Get(client *RedisClient.Client, runID string) (*Run, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client not initialized")
+	}
+
+	if raw, err := client.Get(Key(runID)); err == nil {
+		var run Run
+		if err := json.Unmarshal([]byte(raw), &run); err == nil {
+			return &run, nil
+		}
+	}
+
+	store := storage.GetStore()
+	if store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+	record, err := store.GetRun(context.Background(), runID)
+	if err != nil {
+		return nil, err
+	}
+	return fromRecord(record), nil
+}
+
+// ErrFinished is returned when updating a run that already finished.
+var ErrFinished = errors.New("run already finished")
+
+// updateAttempts bounds how many times Update reapplies a change after a concurrent one.
+const updateAttempts = 5
+
+// Update applies a change to a stored run and saves it.
+// The change is saved only if the run still has the status it was read with:
+// after a concurrent change (e.g. a cancellation while the worker starts the
+// run) the run is read again from the store and the change applied again.
+// A finished run is never changed: ErrFinished is returned.
+func Update(client *RedisClient.Client, runID string, apply func(run *Run)) (*Run, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client not initialized")
+	}
+	store := storage.GetStore()
+	if store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	run, err := Get(client, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run, err = update(store, run, apply); err != nil {
+		return run, err
+	}
+	if err := cache(client, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// update saves a change to a run read from the cache or the store (see Update).
+func update(store storage.Store, run *Run, apply func(run *Run)) (*Run, error) {
+	for attempt := 1; ; attempt++ {
+		if run.Status.IsFinal() {
+			return run, ErrFinished
+		}
+
+		expected := run.Status
+		apply(run)
+		updated, err := store.UpdateRun(context.Background(), run.toRecord(), string(expected))
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			return run, nil
+		}
+		if attempt == updateAttempts {
+			return nil, fmt.Errorf("run %s changed concurrently %d times", run.RunID, updateAttempts)
+		}
+
+		// The cached copy may be stale: read the run from the store
+		record, err := store.GetRun(context.Background(), run.RunID)
+		if err != nil {
+			return nil, err
+		}
+		run = fromRecord(record)
+	}
+}
+
+// updateOrCreate applies a change to the run of a job, creating the run from the
+// job only if it does not exist (record expired, job pushed by an older manager).
+// A run created concurrently is never overwritten: the change is applied to it.
+func updateOrCreate(client *RedisClient.Client, job *queue.Job, status Status, apply func(run *Run)) error {
+	_, err := Update(client, job.RunID, apply)
+	if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	store := storage.GetStore()
+	run := FromJob(job, status)
+	apply(run)
+	inserted, err := store.InsertRun(context.Background(), run.toRecord())
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		_, err = Update(client, job.RunID, apply)
+		return err
+	}
+	return cache(client, run)
+}
+
+// ListByWorkflow returns the runs of a workflow, most recent first, and the total count.
+func ListByWorkflow(workflowID string, offset, limit int) ([]*Run, int64, error) {
+	store := storage.GetStore()
+	if store == nil {
+		return nil, 0, fmt.Errorf("storage not initialized")
+	}
+
+	records, total, err := store.ListRuns(context.Background(), workflowID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]*Run, 0, len(records))
+	for _, record := range records {
+		list = append(list, fromRecord(record))
+	}
+	return list, total, nil
+}
+
+// Enqueue records a run as queued and pushes its job on the execution queue.
+// The record is created if the run is new (e.g. fired by a trigger).
+// Fails with ErrFinished if the run finished meanwhile (e.g. cancelled).
+func Enqueue(client *RedisClient.Client, job *queue.Job) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+
+	now := time.Now().UnixMilli()
+	if err := updateOrCreate(client, job, StatusQueued, func(run *Run) {
+		run.Status = StatusQueued
+		run.QueuedAt = now
+	}); err != nil {
+		return err
+	}
+
+	return queue.Enqueue(client, job)
+}
+
+// Start marks a run as picked up by a worker.
+// A run resuming after a wait or a pause keeps its original start time.
+// Fails with ErrFinished if the run finished meanwhile (e.g. cancelled).
+func Start(client *RedisClient.Client, job *queue.Job) error {
+	now := time.Now().UnixMilli()
+	return updateOrCreate(client, job, StatusRunning, func(run *Run) {
+		run.Status = StatusRunning
+		if run.StartedAt == 0 || !job.Resumed {
+			run.StartedAt = now
+		}
+	})
+}
+
+// Finish records the outcome of a run.
+func Finish(client *RedisClient.Client, runID string, status Status, errMsg string) error {
+	now := time.Now().UnixMilli()
+	_, err := Update(client, runID, func(run *Run) {
+		run.Status = status
+		run.EndedAt = now
+		run.Error = errMsg
+		if run.StartedAt > 0 {
+			run.DurationMs = now - run.StartedAt
+		}
+	})
+	return err
+}
+
+// Suspend marks a run as waiting for an external event, or as paused.
+func Suspend(client *RedisClient.Client, runID string, status Status) error {
+	_, err := Update(client, runID, func(run *Run) {
+		run.Status = status
+	})
+	return err
+}
+
+// Cancel marks a run that is not executing as cancelled.
+func Cancel(client *RedisClient.Client, runID string) error {
+	return Finish(client, runID, StatusCancelled, "")
+}
+
+// Follow delivers the events of a run recorded after afterID ("" for all), then
+// the new ones as the worker records them, until run.finished is delivered,
+// emit returns false or ctx is done. idle is called when nothing was recorded
+// for idleAfter; it returns false to stop following.
+// A run that finished without recording run.finished (cancelled before it
+// started, expired log) is returned so the caller can report its outcome.
+// New events are awaited on the blocking client, bound to ctx, so followers
+// never hold connections of client and release theirs on disconnect.
+func Follow(ctx context.Context, client *RedisClient.Client, runID, afterID string, idleAfter time.Duration,
+	emit func(event runlog.Event) bool, idle func() bool) (*Run, error) {
+	cursor := afterID
+	if cursor == "" {
+		cursor = "0"
+	}
+
+	// deliver reports whether following should go on
+	deliver := func(events []runlog.Event) bool {
+		for _, event := range events {
+			if !emit(event) {
+				return false
+			}
+			cursor = event.ID
+			if event.Type == runlog.RunFinished {
+				return false
+			}
+		}
+		return true
+	}
+
+	events, err := runlog.Events(client, runID, afterID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !deliver(events) {
+		return nil, nil
+	}
+
+	// Blocking reads use the dedicated client and stop as soon as ctx is done
+	blocking := RedisClient.GetBlockingClient().WithContext(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		events, err := runlog.Wait(blocking, runID, cursor, 100, idleAfter)
+		if err == nil {
+			if !deliver(events) {
+				return nil, nil
+			}
+			continue
+		}
+		if !errors.Is(err, RedisClient.ErrTimeout) {
+			return nil, err
+		}
+
+		// No activity: stop if the run is over
+		if run, err := Get(client, runID); err == nil && run.Status.IsFinal() {
+			// Events recorded right before the outcome are still delivered
+			if events, err := runlog.Events(client, runID, cursor, 0); err == nil && !deliver(events) {
+				return nil, nil
+			}
+			return run, nil
+		}
+		if !idle() {
+			return nil, nil
+		}
+	}
+}
+
+// toRecord converts a run to its storage representation.
+func (r *Run) toRecord() *storage.Run {
+	var input json.RawMessage
+	if len(r.Input) > 0 {
+		input, _ = json.Marshal(r.Input)
+	}
+
+	return &storage.Run{
+		ID:            r.RunID,
+		WorkflowID:    r.WorkflowID,
+		Revision:      r.Revision,
+		Status:        string(r.Status),
+		Trigger:       r.Trigger,
+		TriggerNodeID: r.TriggerNodeID,
+		Priority:      r.Priority,
+		CreatedAt:     r.CreatedAt,
+		QueuedAt:      r.QueuedAt,
+		StartedAt:     r.StartedAt,
+		EndedAt:       r.EndedAt,
+		DurationMs:    r.DurationMs,
+		Error:         r.Error,
+		Mode:          r.Mode,
+		RetryOf:       r.RetryOf,
+		RetryFrom:     r.RetryFrom,
+		Input:         input,
+		Graph:         r.Graph,
+	}
+}
+
+// fromRecord converts a stored run.
+func fromRecord(record *storage.Run) *Run {
+	var input map[string]interface{}
+	if len(record.Input) > 0 {
+		json.Unmarshal(record.Input, &input)
+	}
+
+	return &Run{
+		RunID:         record.ID,
+		WorkflowID:    record.WorkflowID,
+		Revision:      record.Revision,
+		Status:        Status(record.Status),
+		Trigger:       record.Trigger,
+		TriggerNodeID: record.TriggerNodeID,
+		Priority:      record.Priority,
+		CreatedAt:     record.CreatedAt,
+		QueuedAt:      record.QueuedAt,
+		StartedAt:     record.StartedAt,
+		EndedAt:       record.EndedAt,
+		DurationMs:    record.DurationMs,
+		Error:         record.Error,
+		Mode:          record.Mode,
+		RetryOf:       record.RetryOf,
+		RetryFrom:     record.RetryFrom,
+		Input:         input,
+	}
+}
+
+// Graph returns the graph submitted with an ad-hoc run, nil for runs of stored workflows.
+func Graph(runID string) (json.RawMessage, error) {
+	store := storage.GetStore()
+	if store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+	return store.GetRunGraph(context.Background(), runID)
+}
+
+// SaveResults persists the node results of a finished run.
+func SaveResults(runID string, results []storage.NodeResult) error {
+	store := storage.GetStore()
+	if store == nil {
+		return fmt.Errorf("storage not initialized")
+	}
+	return store.SaveNodeResults(context.Background(), runID, results)
+}
+
+// Results returns the current execution result of a run.
+// The compacted run log is used when available, otherwise the result is rebuilt from the store.
+func Results(client *RedisClient.Client, runID string) (json.RawMessage, error) {
+	if client != nil {
+		if snapshot, ok, err := runlog.Snapshot(client, runID); err == nil && ok {
+			return snapshot, nil
+		}
+	}
+
+	store := storage.GetStore()
+	if store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	record, err := store.GetRun(context.Background(), runID)
+	if err != nil {
+		return nil, err
+	}
+	if !Status(record.Status).IsFinal() {
+		return nil, fmt.Errorf("run %s has no results yet", runID)
+	}
+
+	nodeResults, err := store.ListNodeResults(context.Background(), runID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(nodeResults))
+	for _, result := range nodeResults {
+		node := map[string]interface{}{
+			"nodeId":     result.NodeID,
+			"nodeType":   result.NodeType,
+			"status":     result.Status,
+			"timestamp":  result.StartedAt,
+			"durationMs": result.DurationMs,
+			"result":     result.Result,
+			"logs":       result.Logs,
+		}
+		if result.Error != "" {
+			node["error"] = result.Error
+		}
+		if result.Branch != "" {
+			node["branch"] = result.Branch
+		}
+		nodes = append(nodes, node)
+	}
+
+	// Same shape as the runner's execution result (times in unix seconds)
+	rebuilt := map[string]interface{}{
+		"runId":         record.ID,
+		"workflowId":    record.WorkflowID,
+		"status":        record.Status,
+		"startedAt":     record.StartedAt / 1000,
+		"endedAt":       record.EndedAt / 1000,
+		"durationMs":    record.DurationMs,
+		"nodes":         nodes,
+		"logs":          []string{},
+		"numberOfNodes": len(nodes),
+		"triggerNodeId": record.TriggerNodeID,
+		"triggerType":   record.Trigger,
+	}
+	if record.Error != "" {
+		rebuilt["error"] = record.Error
+	}
+
+	data, err := json.Marshal(rebuilt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal run results: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,119 @@
+package runs
+
+import (
+	"XKA/internal/shared/storage"
+	"XKA/pkg/logger"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func openTestStore(t *testing.T) storage.Store {
+	t.Helper()
+	store, err := storage.Open(context.Background(), storage.Config{
+		Driver: storage.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "runs.db"),
+	})
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// storeRun saves a run with the given status and returns a copy of it, as read by a caller.
+func storeRun(t *testing.T, store storage.Store, id string, status Status) *Run {
+	t.Helper()
+	run := &Run{RunID: id, WorkflowID: "wf", Status: status, CreatedAt: 1, Mode: "production"}
+	if err := store.SaveRun(context.Background(), run.toRecord()); err != nil {
+		t.Fatalf("failed to save run: %v", err)
+	}
+	copied := *run
+	return &copied
+}
+
+func storedStatus(t *testing.T, store storage.Store, id string) Status {
+	t.Helper()
+	record, err := store.GetRun(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to read run: %v", err)
+	}
+	return Status(record.Status)
+}
+
+func TestUpdate(t *testing.T) {
+	start := func(run *Run) {
+		run.Status = StatusRunning
+		run.StartedAt = 10
+	}
+
+	tests := []struct {
+		name    string
+		read    Status // Status of the copy read by the caller
+		stored  Status // Status in the store when the change is saved
+		want    Status // Status in the store afterwards
+		wantErr error
+	}{
+		{"up to date copy", StatusQueued, StatusQueued, StatusRunning, nil},
+		{"stale copy, change applied again", StatusQueued, StatusPaused, StatusRunning, nil},
+		{"cancelled meanwhile", StatusQueued, StatusCancelled, StatusCancelled, ErrFinished},
+		{"finished meanwhile", StatusRunning, StatusSuccess, StatusSuccess, ErrFinished},
+		{"finished copy", StatusError, StatusError, StatusError, ErrFinished},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := openTestStore(t)
+			storeRun(t, store, "run-1", tt.stored)
+			read := &Run{RunID: "run-1", WorkflowID: "wf", Status: tt.read, CreatedAt: 1, Mode: "production"}
+
+			run, err := update(store, read, start)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("update() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := storedStatus(t, store, "run-1"); got != tt.want {
+				t.Errorf("stored status = %s, want %s", got, tt.want)
+			}
+			if err == nil && (run.Status != StatusRunning || run.StartedAt != 10) {
+				t.Errorf("update() = %+v, want the change applied", run)
+			}
+		})
+	}
+}
+
+func TestUpdateAppliesChangeToFreshCopy(t *testing.T) {
+	store := openTestStore(t)
+	storeRun(t, store, "run-1", StatusQueued)
+
+	// Another caller records an error before our stale copy is saved
+	stored := storeRun(t, store, "run-1", StatusPaused)
+	stored.Error = "kept"
+	if err := store.SaveRun(context.Background(), stored.toRecord()); err != nil {
+		t.Fatalf("failed to save run: %v", err)
+	}
+
+	stale := &Run{RunID: "run-1", WorkflowID: "wf", Status: StatusQueued, CreatedAt: 1, Mode: "production"}
+	run, err := update(store, stale, func(run *Run) { run.Status = StatusQueued })
+	if err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+	if run.Error != "kept" {
+		t.Errorf("update() lost the concurrent change: error = %q", run.Error)
+	}
+}
+
+func TestUpdateMissingRun(t *testing.T) {
+	store := openTestStore(t)
+	missing := &Run{RunID: "missing", Status: StatusQueued}
+	if _, err := update(store, missing, func(run *Run) { run.Status = StatusRunning }); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("update() error = %v, want %v", err, storage.ErrNotFound)
+	}
+}
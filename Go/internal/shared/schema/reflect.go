@@ -0,0 +1,373 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Typed configurations
+//
+// A configuration is declared as a Go struct. Each exported field is a property
+// named after its json tag; the schema tag adds the constraints and the
+// description tag documents it:
+//
+//	type HttpRequestConfig struct {
+//		URL    string `json:"url" schema:"required,format=uri" description:"Requested URL"`
+//		Method string `json:"method" schema:"default=GET,enum=GET|POST" description:"HTTP method"`
+//	}
+//
+// Schema tag options (comma separated, so patterns cannot contain commas):
+// required, default=V, enum=A|B, format=F, pattern=RE, min=N, max=N,
+// minLength=N, maxLength=N.
+//
+// Field types and the JSON values they accept:
+//
+//	string                  a string
+//	bool                    a boolean
+//	int, int64, float64     a number or a numeric string ("5")
+//	time.Duration           a Go duration ("5s", "1h30m"), or milliseconds as a number or a numeric string
+//	[]string                an array of strings or a single string
+//	map[string]interface{}  an object
+//	interface{}             anything
+
+// durationPattern accepts a Go duration or milliseconds.
+const durationPattern = `^([0-9]+|([0-9]*\.?[0-9]+(ns|us|µs|ms|s|m|h))+)$`
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var reflected sync.Map // reflect.Type -> *Schema
+
+// Reflect returns the schema of a configuration struct (or pointer to one).
+// Panics on an unsupported field type or an invalid tag since configurations
+// are declared in code.
+func Reflect(v interface{}) *Schema {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("schema: cannot reflect %T, expected a struct", v))
+	}
+
+	if cached, ok := reflected.Load(t); ok {
+		return cached.(*Schema)
+	}
+	s, err := reflectStruct(t)
+	if err != nil {
+		panic(fmt.Sprintf("schema: %s: %v", t.Name(), err))
+	}
+	if err := s.compile(""); err != nil {
+		panic(fmt.Sprintf("schema: %s: %v", t.Name(), err))
+	}
+	reflected.Store(t, s)
+	return s
+}
+
+// Decode fills a configuration struct from decoded JSON data.
+// Blank strings given to non-string fields are ignored, defaults are applied,
+// then the data is validated against the schema of the struct and converted to
+// the field types. Every problem is reported, like Validate does.
+func Decode(data map[string]interface{}, out interface{}) Errors {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("schema: cannot decode into %T, expected a pointer to a struct", out))
+	}
+	s := Reflect(out)
+
+	filled, errs := s.Apply(withoutBlanks(data, rv.Elem().Type()))
+	if len(errs) > 0 {
+		return errs
+	}
+	values, _ := filled.(map[string]interface{})
+
+	target := rv.Elem()
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		name, ok := propertyName(field)
+		if !ok {
+			continue
+		}
+		raw, present := values[name]
+		if !present || raw == nil {
+			continue
+		}
+		if err := assign(target.Field(i), raw, s.Properties[name]); err != nil {
+			errs = append(errs, FieldError{Path: "/" + escape(name), Code: err.code, Message: err.message})
+		}
+	}
+	return errs
+}
+
+// withoutBlanks copies the data without the empty strings given to fields
+// that are not strings: a blank form field means "not set".
+func withoutBlanks(data map[string]interface{}, t reflect.Type) map[string]interface{} {
+	copied := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		copied[key] = value
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := propertyName(field)
+		if !ok || field.Type.Kind() == reflect.String {
+			continue
+		}
+		if value, isString := copied[name].(string); isString && strings.TrimSpace(value) == "" {
+			delete(copied, name)
+		}
+	}
+	return copied
+}
+
+// propertyName returns the JSON name of an exported struct field.
+func propertyName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false // Unexported
+	}
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+func reflectStruct(t reflect.Type) (*Schema, error) {
+	s := &Schema{Type: Types{TypeObject}, Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := propertyName(field)
+		if !ok {
+			continue
+		}
+
+		property, err := fieldSchema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		property.Description = field.Tag.Get("description")
+
+		required, err := applyTag(property, field.Type, field.Tag.Get("schema"))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = property
+	}
+	return s, nil
+}
+
+// fieldSchema returns the base schema of a field type.
+func fieldSchema(t reflect.Type) (*Schema, error) {
+	if t == durationType {
+		return &Schema{Type: Types{TypeString, TypeNumber}, Pattern: durationPattern}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: Types{TypeString}}, nil
+	case reflect.Bool:
+		return &Schema{Type: Types{TypeBoolean}}, nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return &Schema{Type: Types{TypeInteger, TypeString}, Pattern: `^-?[0-9]+$`}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{TypeNumber, TypeString}, Pattern: `^-?[0-9]+(\.[0-9]+)?$`}, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return &Schema{Type: Types{TypeArray, TypeString}, Items: &Schema{Type: Types{TypeString}}}, nil
+		}
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Interface {
+			return &Schema{Type: Types{TypeObject}}, nil
+		}
+	case reflect.Interface:
+		return &Schema{}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// applyTag reads the schema tag of a field into its property schema.
+func applyTag(s *Schema, t reflect.Type, tag string) (required bool, err error) {
+	if tag == "" {
+		return false, nil
+	}
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "required":
+			required = true
+		case "default":
+			if s.Default, err = tagValue(t, value); err != nil {
+				return false, fmt.Errorf("invalid default: %w", err)
+			}
+		case "enum":
+			for _, item := range strings.Split(value, "|") {
+				v, err := tagValue(t, item)
+				if err != nil {
+					return false, fmt.Errorf("invalid enum value: %w", err)
+				}
+				s.Enum = append(s.Enum, v)
+			}
+		case "format":
+			s.Format = value
+		case "pattern":
+			s.Pattern = value
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if key == "min" {
+				s.Minimum = &n
+			} else {
+				s.Maximum = &n
+			}
+		case "minLength", "maxLength":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if key == "minLength" {
+				s.MinLength = &n
+			} else {
+				s.MaxLength = &n
+			}
+		default:
+			return false, fmt.Errorf("unknown schema option %q", key)
+		}
+	}
+	return required, nil
+}
+
+// tagValue converts a default or enum value written in a tag to its JSON form.
+func tagValue(t reflect.Type, value string) (interface{}, error) {
+	if t == durationType {
+		return value, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	case reflect.Slice:
+		items := []interface{}{}
+		for _, item := range strings.Split(value, "|") {
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return value, nil
+	}
+}
+
+type assignError struct {
+	code    string
+	message string
+}
+
+// assign converts a validated JSON value to the type of a struct field.
+// Numbers given as strings are checked against the minimum and maximum here,
+// since the schema only bounds actual numbers.
+func assign(field reflect.Value, raw interface{}, s *Schema) *assignError {
+	if field.Type() == durationType {
+		d, fromString, err := parseDuration(raw)
+		if err != nil {
+			return &assignError{code: "type", message: err.Error()}
+		}
+		if fromString {
+			if bad := checkBounds(float64(d.Milliseconds()), s); bad != nil {
+				return bad
+			}
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw.(string))
+	case reflect.Bool:
+		field.SetBool(raw.(bool))
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		n, fromString, err := parseNumber(raw)
+		if err != nil {
+			return &assignError{code: "type", message: err.Error()}
+		}
+		if fromString {
+			if bad := checkBounds(n, s); bad != nil {
+				return bad
+			}
+		}
+		if field.Kind() == reflect.Float32 || field.Kind() == reflect.Float64 {
+			field.SetFloat(n)
+		} else {
+			field.SetInt(int64(n))
+		}
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			items = []interface{}{raw} // A single string
+		}
+		slice := reflect.MakeSlice(field.Type(), 0, len(items))
+		for _, item := range items {
+			slice = reflect.Append(slice, reflect.ValueOf(item.(string)))
+		}
+		field.Set(slice)
+	case reflect.Map, reflect.Interface:
+		field.Set(reflect.ValueOf(raw))
+	}
+	return nil
+}
+
+// parseDuration reads a Go duration string, or milliseconds as a number or a numeric string.
+func parseDuration(raw interface{}) (time.Duration, bool, error) {
+	switch v := raw.(type) {
+	case float64:
+		return time.Duration(v * float64(time.Millisecond)), false, nil
+	case string:
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Duration(ms) * time.Millisecond, true, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, true, fmt.Errorf("invalid duration %q", v)
+		}
+		return d, true, nil
+	}
+	return 0, false, fmt.Errorf("expected a duration, got %s", typeOf(raw))
+}
+
+// parseNumber reads a number or a numeric string.
+func parseNumber(raw interface{}) (float64, bool, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, false, nil
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, true, fmt.Errorf("invalid number %q", v)
+		}
+		return n, true, nil
+	}
+	return 0, false, fmt.Errorf("expected a number, got %s", typeOf(raw))
+}
+
+func checkBounds(n float64, s *Schema) *assignError {
+	if s == nil {
+		return nil
+	}
+	if s.Minimum != nil && n < *s.Minimum {
+		return &assignError{code: "minimum", message: fmt.Sprintf("must be at least %v", *s.Minimum)}
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		return &assignError{code: "maximum", message: fmt.Sprintf("must be at most %v", *s.Maximum)}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	URL      string                 `json:"url" schema:"required,format=uri" description:"Requested URL"`
+	Method   string                 `json:"method" schema:"default=GET,enum=GET|POST"`
+	Retries  int                    `json:"retries" schema:"default=3,min=0,max=5"`
+	Ratio    float64                `json:"ratio" schema:"min=0,max=1"`
+	Enabled  bool                   `json:"enabled" schema:"default=true"`
+	Timeout  time.Duration          `json:"timeout" schema:"default=1s,min=1"`
+	Tags     []string               `json:"tags"`
+	Headers  map[string]interface{} `json:"headers"`
+	Body     interface{}            `json:"body"`
+	Ignored  string                 `json:"-"`
+	internal string
+}
+
+func TestReflect(t *testing.T) {
+	s := Reflect(testConfig{})
+
+	if !reflect.DeepEqual(s.Required, []string{"url"}) {
+		t.Errorf("Required = %v, want [url]", s.Required)
+	}
+	if _, ok := s.Properties["Ignored"]; ok {
+		t.Error("json:\"-\" field reflected")
+	}
+	if _, ok := s.Properties["internal"]; ok {
+		t.Error("unexported field reflected")
+	}
+	if len(s.Properties) != 9 {
+		t.Errorf("%d properties reflected, want 9", len(s.Properties))
+	}
+
+	tests := []struct {
+		name    string
+		types   Types
+		def     interface{}
+		enum    []interface{}
+		minimum *float64
+	}{
+		{"url", Types{TypeString}, nil, nil, nil},
+		{"method", Types{TypeString}, "GET", []interface{}{"GET", "POST"}, nil},
+		{"retries", Types{TypeInteger, TypeString}, 3.0, nil, floatPtr(0)},
+		{"ratio", Types{TypeNumber, TypeString}, nil, nil, floatPtr(0)},
+		{"enabled", Types{TypeBoolean}, true, nil, nil},
+		{"timeout", Types{TypeString, TypeNumber}, "1s", nil, floatPtr(1)},
+		{"tags", Types{TypeArray, TypeString}, nil, nil, nil},
+		{"headers", Types{TypeObject}, nil, nil, nil},
+		{"body", nil, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			property := s.Properties[tt.name]
+			if property == nil {
+				t.Fatalf("property %s not reflected", tt.name)
+			}
+			if !reflect.DeepEqual(property.Type, tt.types) {
+				t.Errorf("Type = %v, want %v", property.Type, tt.types)
+			}
+			if !reflect.DeepEqual(property.Default, tt.def) {
+				t.Errorf("Default = %#v, want %#v", property.Default, tt.def)
+			}
+			if !reflect.DeepEqual(property.Enum, tt.enum) {
+				t.Errorf("Enum = %v, want %v", property.Enum, tt.enum)
+			}
+			if !reflect.DeepEqual(property.Minimum, tt.minimum) {
+				t.Errorf("Minimum = %v, want %v", property.Minimum, tt.minimum)
+			}
+		})
+	}
+
+	if got := s.Properties["url"].Description; got != "Requested URL" {
+		t.Errorf("Description = %q, want %q", got, "Requested URL")
+	}
+	if Reflect(&testConfig{}) != s {
+		t.Error("Reflect of a pointer does not return the cached schema")
+	}
+}
+
+func TestReflectPanics(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"not a struct", "config"},
+		{"unsupported type", struct {
+			Items []int `json:"items"`
+		}{}},
+		{"unknown option", struct {
+			Name string `json:"name" schema:"requird"`
+		}{}},
+		{"invalid default", struct {
+			Count int `json:"count" schema:"default=many"`
+		}{}},
+		{"default out of bounds", struct {
+			Count int `json:"count" schema:"default=0,min=1"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Reflect(%T) did not panic", tt.value)
+				}
+			}()
+			Reflect(tt.value)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want testConfig
+	}{
+		{
+			name: "defaults",
+			data: map[string]interface{}{"url": "https://example.com"},
+			want: testConfig{URL: "https://example.com", Method: "GET", Retries: 3, Enabled: true, Timeout: time.Second},
+		},
+		{
+			name: "values",
+			data: map[string]interface{}{
+				"url": "https://example.com", "method": "POST", "retries": 2.0, "ratio": 0.5, "enabled": false,
+				"timeout": "1m30s", "tags": []interface{}{"a", "b"}, "headers": map[string]interface{}{"X": "y"}, "body": 1.0,
+			},
+			want: testConfig{
+				URL: "https://example.com", Method: "POST", Retries: 2, Ratio: 0.5, Enabled: false,
+				Timeout: 90 * time.Second, Tags: []string{"a", "b"}, Headers: map[string]interface{}{"X": "y"}, Body: 1.0,
+			},
+		},
+		{
+			name: "numeric strings",
+			data: map[string]interface{}{"url": "https://example.com", "retries": "4", "ratio": "0.25"},
+			want: testConfig{URL: "https://example.com", Method: "GET", Retries: 4, Ratio: 0.25, Enabled: true, Timeout: time.Second},
+		},
+		{
+			name: "single string as a list",
+			data: map[string]interface{}{"url": "https://example.com", "tags": "a"},
+			want: testConfig{URL: "https://example.com", Method: "GET", Retries: 3, Enabled: true, Timeout: time.Second, Tags: []string{"a"}},
+		},
+		{
+			name: "blank strings ignored",
+			data: map[string]interface{}{"url": "https://example.com", "retries": "", "timeout": " ", "tags": ""},
+			want: testConfig{URL: "https://example.com", Method: "GET", Retries: 3, Enabled: true, Timeout: time.Second},
+		},
+		{
+			name: "null values ignored",
+			data: map[string]interface{}{"url": "https://example.com", "body": nil},
+			want: testConfig{URL: "https://example.com", Method: "GET", Retries: 3, Enabled: true, Timeout: time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testConfig
+			if errs := Decode(tt.data, &got); len(errs) > 0 {
+				t.Fatalf("Decode() errors = %v", errs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  interface{}
+		want time.Duration
+	}{
+		{"go duration", "5s", 5 * time.Second},
+		{"compound duration", "1h30m", 90 * time.Minute},
+		{"fractional duration", "1.5s", 1500 * time.Millisecond},
+		{"milliseconds as a number", 250.0, 250 * time.Millisecond},
+		{"milliseconds as a string", "1500", 1500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testConfig
+			if errs := Decode(map[string]interface{}{"url": "https://example.com", "timeout": tt.raw}, &got); len(errs) > 0 {
+				t.Fatalf("Decode(%v) errors = %v", tt.raw, errs)
+			}
+			if got.Timeout != tt.want {
+				t.Errorf("Decode(%v) = %v, want %v", tt.raw, got.Timeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []string
+	}{
+		{"missing required", map[string]interface{}{}, []string{"/url required"}},
+		{"not in enum", map[string]interface{}{"url": "https://example.com", "method": "PUT"}, []string{"/method enum"}},
+		{"wrong type", map[string]interface{}{"url": "https://example.com", "enabled": "yes"}, []string{"/enabled type"}},
+		{"not numeric", map[string]interface{}{"url": "https://example.com", "retries": "many"}, []string{"/retries pattern"}},
+		{"number above maximum", map[string]interface{}{"url": "https://example.com", "retries": 6.0}, []string{"/retries maximum"}},
+		{"numeric string above maximum", map[string]interface{}{"url": "https://example.com", "retries": "6"}, []string{"/retries maximum"}},
+		{"invalid duration", map[string]interface{}{"url": "https://example.com", "timeout": "5 seconds"}, []string{"/timeout pattern"}},
+		{"duration below minimum", map[string]interface{}{"url": "https://example.com", "timeout": 0.0}, []string{"/timeout minimum"}},
+		{"duration string below minimum", map[string]interface{}{"url": "https://example.com", "timeout": "0"}, []string{"/timeout minimum"}},
+		{"every problem reported", map[string]interface{}{"method": "PUT", "retries": 6.0}, []string{"/url required", "/method enum", "/retries maximum"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testConfig
+			if errs := codes(Decode(tt.data, &got)); !reflect.DeepEqual(errs, tt.want) {
+				t.Errorf("Decode(%v) errors = %v, want %v", tt.data, errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDoesNotModifyData(t *testing.T) {
+	data := map[string]interface{}{"url": "https://example.com", "retries": ""}
+	var got testConfig
+	Decode(data, &got)
+	if len(data) != 2 || data["retries"] != "" {
+		t.Errorf("Decode() modified its input: %v", data)
+	}
+}
+
+func floatPtr(n float64) *float64 {
+	return &n
+}
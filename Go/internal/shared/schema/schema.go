@@ -0,0 +1,525 @@
+// Package schema implements the subset of JSON Schema used to describe
+// the data exchanged with workflows (run inputs, node configurations).
+//
+// Supported keywords: type (a name or a list of names), properties, required,
+// additionalProperties (boolean), items, enum, default, minimum, maximum,
+// minLength, maxLength, pattern, format (uri, date-time, duration), minItems,
+// maxItems, title and description. Other keywords are ignored.
+//
+// Validation reports every problem found, each located by a JSON pointer
+// (RFC 6901) relative to the validated value and identified by the keyword
+// that failed.
+//
+// Node configurations are declared as tagged Go structs: Reflect generates
+// their schema and Decode fills them from node data (see reflect.go).
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Type names.
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+var knownTypes = map[string]bool{
+	TypeObject: true, TypeArray: true, TypeString: true, TypeNumber: true,
+	TypeInteger: true, TypeBoolean: true, TypeNull: true,
+}
+
+// Formats checked on strings.
+const (
+	FormatURI      = "uri"       // Absolute URL with a scheme and a host
+	FormatDateTime = "date-time" // RFC 3339 timestamp
+	FormatDuration = "duration"  // Go duration ("500ms", "5s", "1h30m")
+)
+
+var knownFormats = map[string]bool{FormatURI: true, FormatDateTime: true, FormatDuration: true}
+
+// Types is the "type" keyword: a single type name or a list of them.
+type Types []string
+
+// UnmarshalJSON accepts a string or an array of strings.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+// MarshalJSON writes a single type as a string.
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Schema describes the expected shape of a JSON value.
+type Schema struct {
+	Type                 Types              `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// FieldError is a validation problem.
+type FieldError struct {
+	Path    string `json:"path"`    // JSON pointer of the offending value ("" for the root)
+	Code    string `json:"code"`    // Keyword that failed (type, required, enum, minimum, ...)
+	Message string `json:"message"` // Human readable description
+}
+
+// Errors is the list of problems found by a validation.
+type Errors []FieldError
+
+// Error implements the error interface.
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		if fieldErr.Path == "" {
+			messages = append(messages, fieldErr.Message)
+			continue
+		}
+		messages = append(messages, fieldErr.Path+": "+fieldErr.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Prefixed returns the errors with their paths moved under a JSON pointer prefix.
+func (e Errors) Prefixed(prefix string) Errors {
+	prefixed := make(Errors, len(e))
+	for i, fieldErr := range e {
+		fieldErr.Path = prefix + fieldErr.Path
+		prefixed[i] = fieldErr
+	}
+	return prefixed
+}
+
+// Parse reads a schema from its JSON form or from decoded JSON (map[string]interface{})
+// and checks that it is well formed.
+func Parse(raw interface{}) (*Schema, error) {
+	var data []byte
+	switch v := raw.(type) {
+	case json.RawMessage:
+		data = v
+	case []byte:
+		data = v
+	default:
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema: %w", err)
+		}
+		data = encoded
+	}
+
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.compile(""); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// MustParse is like Parse but panics on an invalid schema.
+// It is meant for schemas declared in code.
+func MustParse(raw string) *Schema {
+	s, err := Parse(json.RawMessage(raw))
+	if err != nil {
+		panic(fmt.Sprintf("schema: %v", err))
+	}
+	return s
+}
+
+// compile checks the schema and prepares its patterns.
+func (s *Schema) compile(path string) error {
+	for _, name := range s.Type {
+		if !knownTypes[name] {
+			return fmt.Errorf("invalid schema at %q: unknown type %q", pointer(path), name)
+		}
+	}
+	if s.Format != "" && !knownFormats[s.Format] {
+		return fmt.Errorf("invalid schema at %q: unknown format %q", pointer(path), s.Format)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema at %q: invalid pattern: %v", pointer(path), err)
+		}
+		s.pattern = re
+	}
+	for _, name := range s.Required {
+		if s.Properties != nil && s.Properties[name] == nil && s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			return fmt.Errorf("invalid schema at %q: required property %q is not declared", pointer(path), name)
+		}
+	}
+
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("invalid schema at %q: property %q has no schema", pointer(path), name)
+		}
+		if err := property.compile(path + "/properties/" + escape(name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(path + "/items"); err != nil {
+			return err
+		}
+	}
+
+	// A default must satisfy its own schema
+	if s.Default != nil {
+		if errs := s.Validate(s.Default); len(errs) > 0 {
+			return fmt.Errorf("invalid schema at %q: default does not match the schema: %v", pointer(path), errs)
+		}
+	}
+	return nil
+}
+
+// Validate checks a decoded JSON value against the schema.
+func (s *Schema) Validate(value interface{}) Errors {
+	var errs Errors
+	s.validate(normalize(value), "", &errs)
+	return errs
+}
+
+// Apply fills in the defaults declared by the schema, then validates the result.
+// The value is not modified; the returned value is a copy with the defaults applied.
+func (s *Schema) Apply(value interface{}) (interface{}, Errors) {
+	filled := s.applyDefaults(normalize(value))
+	return filled, s.Validate(filled)
+}
+
+func (s *Schema) applyDefaults(value interface{}) interface{} {
+	if value == nil {
+		if s.Default != nil {
+			return normalize(clone(s.Default))
+		}
+		if !s.Type.allows(TypeObject) || len(s.Properties) == 0 {
+			return nil
+		}
+		// Missing object with properties: build it from the defaults of its properties
+		value = map[string]interface{}{}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		filled := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			filled[key] = item
+		}
+		for name, property := range s.Properties {
+			if item, ok := filled[name]; ok {
+				filled[name] = property.applyDefaults(item)
+			} else if property.Default != nil {
+				filled[name] = normalize(clone(property.Default))
+			} else if property.Type.allows(TypeObject) && len(property.Properties) > 0 && hasDefaults(property) {
+				filled[name] = property.applyDefaults(nil)
+			}
+		}
+		return filled
+	case []interface{}:
+		if s.Items == nil {
+			return v
+		}
+		filled := make([]interface{}, len(v))
+		for i, item := range v {
+			filled[i] = s.Items.applyDefaults(item)
+		}
+		return filled
+	default:
+		return value
+	}
+}
+
+// hasDefaults reports whether an object schema declares defaults for any of its properties.
+func hasDefaults(s *Schema) bool {
+	for _, property := range s.Properties {
+		if property.Default != nil || (len(property.Properties) > 0 && hasDefaults(property)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) validate(value interface{}, path string, errs *Errors) {
+	add := func(code, format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Path: path, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(value) {
+		add("type", "expected %s, got %s", strings.Join(s.Type, " or "), typeOf(value))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, candidate := range s.Enum {
+			if equal(normalize(candidate), value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("enum", "must be one of %s", formatEnum(s.Enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, FieldError{
+					Path:    path + "/" + escape(name),
+					Code:    "required",
+					Message: fmt.Sprintf("%s is required", name),
+				})
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names) // Stable error order
+
+		for _, name := range names {
+			property, declared := s.Properties[name]
+			if declared {
+				property.validate(v[name], path+"/"+escape(name), errs)
+				continue
+			}
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, FieldError{
+					Path:    path + "/" + escape(name),
+					Code:    "additionalProperties",
+					Message: fmt.Sprintf("unknown property %s", name),
+				})
+			}
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			add("minItems", "must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			add("maxItems", "must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, fmt.Sprintf("%s/%d", path, i), errs)
+			}
+		}
+
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			add("minLength", "must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			add("maxLength", "must be at most %d characters long", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			add("pattern", "must match %s", s.Pattern)
+		}
+		if s.Format != "" {
+			if err := checkFormat(s.Format, v); err != nil {
+				add("format", "%v", err)
+			}
+		}
+
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			add("minimum", "must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			add("maximum", "must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+// allows reports whether a type name is accepted (no type accepts everything).
+func (t Types) allows(name string) bool {
+	if len(t) == 0 {
+		return true
+	}
+	for _, candidate := range t {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a value has one of the types.
+func (t Types) matches(value interface{}) bool {
+	for _, name := range t {
+		switch name {
+		case TypeInteger:
+			if n, ok := value.(float64); ok && n == math.Trunc(n) {
+				return true
+			}
+		case typeOf(value):
+			return true
+		}
+	}
+	return false
+}
+
+func checkFormat(format, value string) error {
+	switch format {
+	case FormatURI:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("must be an absolute URL")
+		}
+	case FormatDateTime:
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("must be an RFC 3339 date-time")
+		}
+	case FormatDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("must be a duration such as \"500ms\" or \"5s\"")
+		}
+	}
+	return nil
+}
+
+// typeOf returns the JSON type name of a normalized value.
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return TypeNull
+	case map[string]interface{}:
+		return TypeObject
+	case []interface{}:
+		return TypeArray
+	case string:
+		return TypeString
+	case float64:
+		return TypeNumber
+	case bool:
+		return TypeBoolean
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// normalize converts Go values to their decoded JSON equivalent
+// (numbers become float64, typed maps and slices become generic ones).
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, float64, map[string]interface{}, []interface{}:
+		return v
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if n, err := v.Float64(); err == nil {
+			return n
+		}
+		return v.String()
+	}
+
+	// Anything else goes through a JSON round trip
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// clone deep-copies a decoded JSON value so defaults are never shared.
+func clone(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = clone(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = clone(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+func equal(a, b interface{}) bool {
+	left, errLeft := json.Marshal(a)
+	right, errRight := json.Marshal(b)
+	return errLeft == nil && errRight == nil && string(left) == string(right)
+}
+
+func formatEnum(values []interface{}) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		data, _ := json.Marshal(value)
+		parts = append(parts, string(data))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// escape encodes a property name as a JSON pointer token.
+func escape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,215 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{"empty", `{}`, false},
+		{"single type", `{"type": "string"}`, false},
+		{"type list", `{"type": ["string", "null"]}`, false},
+		{"unknown type", `{"type": "text"}`, true},
+		{"unknown format", `{"type": "string", "format": "email"}`, true},
+		{"invalid pattern", `{"type": "string", "pattern": "("}`, true},
+		{"nested unknown type", `{"type": "object", "properties": {"a": {"type": "text"}}}`, true},
+		{"default matching", `{"type": "integer", "default": 3, "minimum": 1}`, false},
+		{"default out of bounds", `{"type": "integer", "default": 0, "minimum": 1}`, true},
+		{"undeclared required", `{"type": "object", "properties": {}, "required": ["a"], "additionalProperties": false}`, true},
+		{"not json", `{`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(json.RawMessage(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%s) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := MustParse(`{
+		"type": "object",
+		"required": ["name"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "maxLength": 5},
+			"count": {"type": "integer", "minimum": 1, "maximum": 10},
+			"mode": {"type": "string", "enum": ["a", "b"]},
+			"code": {"type": "string", "pattern": "^[A-Z]+$"},
+			"url": {"type": "string", "format": "uri"},
+			"at": {"type": "string", "format": "date-time"},
+			"every": {"type": "string", "format": "duration"},
+			"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2},
+			"label": {"type": ["string", "null"]}
+		}
+	}`)
+
+	tests := []struct {
+		name  string
+		value string
+		want  []FieldError // Path and code only
+	}{
+		{"valid", `{"name": "abc", "count": 3, "mode": "a", "tags": ["x"], "label": null}`, nil},
+		{"integer as float", `{"name": "abc", "count": 3.0}`, nil},
+		{"not an object", `"abc"`, []FieldError{{Path: "", Code: "type"}}},
+		{"missing required", `{}`, []FieldError{{Path: "/name", Code: "required"}}},
+		{"unknown property", `{"name": "abc", "extra": 1}`, []FieldError{{Path: "/extra", Code: "additionalProperties"}}},
+		{"too short", `{"name": "a"}`, []FieldError{{Path: "/name", Code: "minLength"}}},
+		{"too long", `{"name": "abcdef"}`, []FieldError{{Path: "/name", Code: "maxLength"}}},
+		{"fractional integer", `{"name": "abc", "count": 1.5}`, []FieldError{{Path: "/count", Code: "type"}}},
+		{"below minimum", `{"name": "abc", "count": 0}`, []FieldError{{Path: "/count", Code: "minimum"}}},
+		{"above maximum", `{"name": "abc", "count": 11}`, []FieldError{{Path: "/count", Code: "maximum"}}},
+		{"not in enum", `{"name": "abc", "mode": "c"}`, []FieldError{{Path: "/mode", Code: "enum"}}},
+		{"pattern", `{"name": "abc", "code": "abc"}`, []FieldError{{Path: "/code", Code: "pattern"}}},
+		{"relative url", `{"name": "abc", "url": "/path"}`, []FieldError{{Path: "/url", Code: "format"}}},
+		{"bad date-time", `{"name": "abc", "at": "yesterday"}`, []FieldError{{Path: "/at", Code: "format"}}},
+		{"bad duration", `{"name": "abc", "every": "5 seconds"}`, []FieldError{{Path: "/every", Code: "format"}}},
+		{"too few items", `{"name": "abc", "tags": []}`, []FieldError{{Path: "/tags", Code: "minItems"}}},
+		{"too many items", `{"name": "abc", "tags": ["x", "y", "z"]}`, []FieldError{{Path: "/tags", Code: "maxItems"}}},
+		{"bad item", `{"name": "abc", "tags": [1]}`, []FieldError{{Path: "/tags/0", Code: "type"}}},
+		{"multi-type mismatch", `{"name": "abc", "label": 1}`, []FieldError{{Path: "/label", Code: "type"}}},
+		{"every problem reported", `{"count": 0, "mode": "c"}`, []FieldError{
+			{Path: "/name", Code: "required"},
+			{Path: "/count", Code: "minimum"},
+			{Path: "/mode", Code: "enum"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+			if got := codes(s.Validate(value)); !reflect.DeepEqual(got, codes(tt.want)) {
+				t.Errorf("Validate(%s) = %v, want %v", tt.value, got, codes(tt.want))
+			}
+		})
+	}
+}
+
+func TestValidateGoValues(t *testing.T) {
+	s := MustParse(`{"type": "integer", "minimum": 1}`)
+	if errs := s.Validate(2); len(errs) > 0 {
+		t.Errorf("Validate(int) = %v, want no error", errs)
+	}
+	if errs := s.Validate(int64(0)); len(errs) != 1 || errs[0].Code != "minimum" {
+		t.Errorf("Validate(int64) = %v, want a minimum error", errs)
+	}
+
+	object := MustParse(`{"type": "object", "properties": {"count": {"type": "integer", "minimum": 1}}}`)
+	if errs := object.Validate(map[string]int{"count": 0}); len(errs) != 1 || errs[0].Code != "minimum" {
+		t.Errorf("Validate(map[string]int) = %v, want a minimum error", errs)
+	}
+}
+
+func TestApply(t *testing.T) {
+	s := MustParse(`{
+		"type": "object",
+		"properties": {
+			"method": {"type": "string", "default": "GET"},
+			"retries": {"type": "integer", "default": 3},
+			"headers": {"type": "object", "default": {}},
+			"options": {
+				"type": "object",
+				"properties": {"timeout": {"type": "integer", "default": 30}}
+			},
+			"items": {
+				"type": "array",
+				"items": {"type": "object", "properties": {"weight": {"type": "number", "default": 1}}}
+			}
+		}
+	}`)
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"missing object", `null`, `{"method": "GET", "retries": 3, "headers": {}, "options": {"timeout": 30}}`},
+		{"defaults filled", `{}`, `{"method": "GET", "retries": 3, "headers": {}, "options": {"timeout": 30}}`},
+		{"values kept", `{"method": "POST", "retries": 0}`, `{"method": "POST", "retries": 0, "headers": {}, "options": {"timeout": 30}}`},
+		{"nested object", `{"options": {}}`, `{"method": "GET", "retries": 3, "headers": {}, "options": {"timeout": 30}}`},
+		{"array items", `{"items": [{}, {"weight": 2}]}`, `{"method": "GET", "retries": 3, "headers": {}, "options": {"timeout": 30}, "items": [{"weight": 1}, {"weight": 2}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value, want interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("invalid expected value: %v", err)
+			}
+			got, errs := s.Apply(value)
+			if len(errs) > 0 {
+				t.Fatalf("Apply(%s) errors = %v", tt.value, errs)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply(%s) = %v, want %v", tt.value, got, want)
+			}
+		})
+	}
+}
+
+func TestApplyDoesNotShareDefaults(t *testing.T) {
+	s := MustParse(`{"type": "object", "properties": {"headers": {"type": "object", "default": {}}}}`)
+	first, _ := s.Apply(map[string]interface{}{})
+	first.(map[string]interface{})["headers"].(map[string]interface{})["x"] = "y"
+
+	second, _ := s.Apply(map[string]interface{}{})
+	if headers := second.(map[string]interface{})["headers"].(map[string]interface{}); len(headers) != 0 {
+		t.Errorf("default modified by a previous Apply: %v", headers)
+	}
+}
+
+func TestApplyValidates(t *testing.T) {
+	s := MustParse(`{"type": "object", "required": ["url"], "properties": {"url": {"type": "string"}, "method": {"type": "string", "default": "GET"}}}`)
+	_, errs := s.Apply(map[string]interface{}{})
+	if got := codes(errs); !reflect.DeepEqual(got, codes([]FieldError{{Path: "/url", Code: "required"}})) {
+		t.Errorf("Apply({}) = %v, want a required error on /url", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errs := Errors{
+		{Path: "", Code: "type", Message: "expected object, got string"},
+		{Path: "/url", Code: "required", Message: "url is required"},
+	}
+	if got, want := errs.Error(), "expected object, got string; /url: url is required"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	prefixed := errs.Prefixed("/nodes/0/data")
+	if prefixed[0].Path != "/nodes/0/data" || prefixed[1].Path != "/nodes/0/data/url" {
+		t.Errorf("Prefixed() paths = %q, %q", prefixed[0].Path, prefixed[1].Path)
+	}
+	if errs[1].Path != "/url" {
+		t.Errorf("Prefixed() modified the receiver: %q", errs[1].Path)
+	}
+}
+
+func TestEscapedPaths(t *testing.T) {
+	s := MustParse(`{"type": "object", "required": ["a/b", "c~d"]}`)
+	got := codes(s.Validate(map[string]interface{}{}))
+	want := []string{"/a~1b required", "/c~0d required"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v, want %v", got, want)
+	}
+}
+
+// codes keeps the path and the code of each error, in order.
+func codes(errs []FieldError) []string {
+	var out []string
+	for _, err := range errs {
+		out = append(out, err.Path+" "+err.Code)
+	}
+	return out
+}
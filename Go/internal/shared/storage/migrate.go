@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"XKA/pkg/logger"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Migrations are plain SQL files named NNNN_description.sql, one directory per dialect.
+// They are applied in order, each in its own transaction, and recorded in schema_migrations.
+//
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the postgres advisory lock serializing concurrent migrations.
+const migrationLockID = 7241001
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations returns the migrations of a dialect sorted by version.
+func loadMigrations(dialect string) ([]migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %w", dialect, err)
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok || !strings.HasSuffix(name, ".sql") {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s", name)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// migrate applies the pending migrations.
+func (s *sqlStore) migrate(ctx context.Context) error {
+	migrations, err := loadMigrations(s.dialect)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at BIGINT NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		err := s.withTx(ctx, func(tx *sql.Tx) error {
+			if s.dialect == DriverPostgres {
+				if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+					return fmt.Errorf("failed to lock migrations: %w", err)
+				}
+			}
+
+			var applied int
+			if err := tx.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), m.version).Scan(&applied); err != nil {
+				return fmt.Errorf("failed to read schema_migrations: %w", err)
+			}
+			if applied > 0 {
+				return nil
+			}
+
+			if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+				return fmt.Errorf("migration %s failed: %w", m.name, err)
+			}
+			if _, err := s.exec(ctx, tx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, m.version, time.Now().UnixMilli()); err != nil {
+				return fmt.Errorf("failed to record migration %s: %w", m.name, err)
+			}
+
+			logger.Log.Info("Storage migration applied",
+				zap.String("dialect", s.dialect),
+				zap.String("migration", m.name),
+			)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dialect := range []string{DriverSQLite, DriverPostgres} {
+		t.Run(dialect, func(t *testing.T) {
+			migrations, err := loadMigrations(dialect)
+			if err != nil {
+				t.Fatalf("loadMigrations() error = %v", err)
+			}
+			if len(migrations) == 0 {
+				t.Fatal("loadMigrations() found no migration")
+			}
+			// Versions are contiguous from 1, so every dialect gets every change
+			for i, m := range migrations {
+				if m.version != i+1 {
+					t.Errorf("migration %s has version %d, want %d", m.name, m.version, i+1)
+				}
+				if m.sql == "" {
+					t.Errorf("migration %s is empty", m.name)
+				}
+			}
+		})
+	}
+
+	sqlite, _ := loadMigrations(DriverSQLite)
+	postgres, _ := loadMigrations(DriverPostgres)
+	if !reflect.DeepEqual(migrationNames(sqlite), migrationNames(postgres)) {
+		t.Errorf("dialects have different migrations: %v and %v", migrationNames(sqlite), migrationNames(postgres))
+	}
+
+	if _, err := loadMigrations("oracle"); err == nil {
+		t.Error("loadMigrations() of an unknown dialect succeeded, want an error")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.db")
+	migrations, err := loadMigrations(DriverSQLite)
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+
+	// Reopening applies nothing twice
+	for i := 0; i < 2; i++ {
+		store, err := Open(ctx, Config{Driver: DriverSQLite, DSN: path})
+		if err != nil {
+			t.Fatalf("Open() #%d error = %v", i+1, err)
+		}
+		versions := appliedVersions(t, store.(*sqlStore))
+		store.Close()
+
+		if len(versions) != len(migrations) || versions[len(versions)-1] != migrations[len(migrations)-1].version {
+			t.Errorf("Open() #%d recorded versions %v, want 1 to %d", i+1, versions, len(migrations))
+		}
+	}
+}
+
+func appliedVersions(t *testing.T, s *sqlStore) []int {
+	t.Helper()
+	rows, err := s.db.Query(`SELECT version FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		t.Fatalf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			t.Fatalf("failed to read schema_migrations: %v", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func migrationNames(migrations []migration) []string {
+	names := make([]string, 0, len(migrations))
+	for _, m := range migrations {
+		names = append(names, m.name)
+	}
+	return names
+}
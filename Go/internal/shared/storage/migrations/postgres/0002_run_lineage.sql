@@ -0,0 +1,7 @@
+-- Retries point at the run they were created from and the node they re-execute from
+ALTER TABLE runs ADD COLUMN retry_of TEXT NOT NULL DEFAULT '';
+ALTER TABLE runs ADD COLUMN retry_from TEXT NOT NULL DEFAULT '';
+
+-- Validated run input, and the graph of ad-hoc submissions (stored workflows keep theirs in workflow_revisions)
+ALTER TABLE runs ADD COLUMN input TEXT NOT NULL DEFAULT '';
+ALTER TABLE runs ADD COLUMN graph TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Branch taken by a node (approval rejected, wait timed out...), empty for the default output
+ALTER TABLE node_results ADD COLUMN branch TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,53 @@
+-- Workflow definitions: one row per workflow pointing at its latest revision
+CREATE TABLE IF NOT EXISTS workflows (
+    id         TEXT    PRIMARY KEY,
+    revision   INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+-- Immutable snapshots of every saved version of a workflow
+CREATE TABLE IF NOT EXISTS workflow_revisions (
+    workflow_id   TEXT    NOT NULL REFERENCES workflows (id) ON DELETE CASCADE,
+    revision      INTEGER NOT NULL,
+    name          TEXT    NOT NULL DEFAULT '',
+    description   TEXT    NOT NULL DEFAULT '',
+    priority      TEXT    NOT NULL DEFAULT 'normal',
+    graph         TEXT    NOT NULL,
+    restored_from INTEGER NOT NULL DEFAULT 0,
+    created_at    INTEGER NOT NULL,
+    PRIMARY KEY (workflow_id, revision)
+);
+
+-- Runs are kept when their workflow is deleted, and ad-hoc submissions have no stored workflow
+CREATE TABLE IF NOT EXISTS runs (
+    id              TEXT    PRIMARY KEY,
+    workflow_id     TEXT    NOT NULL,
+    revision        INTEGER NOT NULL DEFAULT 0,
+    status          TEXT    NOT NULL,
+    trigger_kind    TEXT    NOT NULL DEFAULT '',
+    trigger_node_id TEXT    NOT NULL DEFAULT '',
+    priority        TEXT    NOT NULL DEFAULT '',
+    created_at      INTEGER NOT NULL,
+    queued_at       INTEGER NOT NULL DEFAULT 0,
+    started_at      INTEGER NOT NULL DEFAULT 0,
+    ended_at        INTEGER NOT NULL DEFAULT 0,
+    duration_ms     INTEGER NOT NULL DEFAULT 0,
+    error           TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS runs_workflow_created_idx ON runs (workflow_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS node_results (
+    run_id      TEXT    NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+    seq         INTEGER NOT NULL,
+    node_id     TEXT    NOT NULL,
+    node_type   TEXT    NOT NULL DEFAULT '',
+    status      TEXT    NOT NULL,
+    started_at  INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    result      TEXT    NOT NULL DEFAULT 'null',
+    error       TEXT    NOT NULL DEFAULT '',
+    logs        TEXT    NOT NULL DEFAULT '[]',
+    PRIMARY KEY (run_id, seq)
+);
@@ -0,0 +1,2 @@
+-- Run mode: production, or development (pinned node outputs are used instead of executing the nodes)
+ALTER TABLE runs ADD COLUMN mode TEXT NOT NULL DEFAULT 'production';
@@ -0,0 +1,484 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers the "pgx" database/sql driver
+	_ "modernc.org/sqlite"             // Registers the "sqlite" database/sql driver (pure Go, no cgo)
+)
+
+// sqlStore implements Store on top of database/sql.
+// SQLite and PostgreSQL share the same queries; only placeholders differ.
+type sqlStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// openSQLite opens (or creates) a SQLite database file.
+func openSQLite(ctx context.Context, path string) (Store, error) {
+	dsn := path
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	// WAL, a busy timeout and immediate write transactions let the worker manager
+	// and the workers share the file without "database is locked" errors
+	dsn += sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	return newSQLStore(ctx, db, DriverSQLite)
+}
+
+// openPostgres connects to a PostgreSQL database.
+func openPostgres(ctx context.Context, dsn string) (Store, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres database: %w", err)
+	}
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+	return newSQLStore(ctx, db, DriverPostgres)
+}
+
+func newSQLStore(ctx context.Context, db *sql.DB, dialect string) (Store, error) {
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s database: %w", dialect, err)
+	}
+
+	store := &sqlStore{db: db, dialect: dialect}
+	if err := store.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Close implements Store.
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// rebind converts '?' placeholders to the dialect syntax ($1, $2... for postgres).
+func (s *sqlStore) rebind(query string) string {
+	if s.dialect != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *sqlStore) exec(ctx context.Context, q execer, query string, args ...interface{}) (sql.Result, error) {
+	return q.ExecContext(ctx, s.rebind(query), args...)
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// withTx runs fn in a transaction, committing on success.
+func (s *sqlStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// --- Workflows ---
+
+const workflowColumns = `r.workflow_id, r.revision, r.name, r.description, r.priority, r.graph, r.restored_from, w.created_at, r.created_at`
+
+// CreateWorkflow implements Store.
+func (s *sqlStore) CreateWorkflow(ctx context.Context, wf *Workflow) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		now := time.Now().UnixMilli()
+		res, err := s.exec(ctx, tx,
+			`INSERT INTO workflows (id, revision, created_at, updated_at) VALUES (?, 1, ?, ?) ON CONFLICT (id) DO NOTHING`,
+			wf.ID, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert workflow: %w", err)
+		}
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return ErrExists
+		}
+
+		wf.Revision = 1
+		wf.CreatedAt = now
+		wf.UpdatedAt = now
+		return s.insertRevision(ctx, tx, wf)
+	})
+}
+
+// AddWorkflowRevision implements Store.
+func (s *sqlStore) AddWorkflowRevision(ctx context.Context, wf *Workflow) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		now := time.Now().UnixMilli()
+
+		// The row update serializes concurrent saves of the same workflow
+		var revision int
+		var createdAt int64
+		err := tx.QueryRowContext(ctx, s.rebind(
+			`UPDATE workflows SET revision = revision + 1, updated_at = ? WHERE id = ? RETURNING revision, created_at`),
+			now, wf.ID).Scan(&revision, &createdAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to allocate workflow revision: %w", err)
+		}
+
+		wf.Revision = revision
+		wf.CreatedAt = createdAt
+		wf.UpdatedAt = now
+		return s.insertRevision(ctx, tx, wf)
+	})
+}
+
+func (s *sqlStore) insertRevision(ctx context.Context, tx *sql.Tx, wf *Workflow) error {
+	graph := wf.Graph
+	if len(graph) == 0 {
+		graph = json.RawMessage(`{}`)
+	}
+
+	_, err := s.exec(ctx, tx,
+		`INSERT INTO workflow_revisions (workflow_id, revision, name, description, priority, graph, restored_from, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		wf.ID, wf.Revision, wf.Name, wf.Description, wf.Priority, string(graph), wf.RestoredFrom, wf.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert workflow revision: %w", err)
+	}
+	return nil
+}
+
+// GetWorkflow implements Store.
+func (s *sqlStore) GetWorkflow(ctx context.Context, id string) (*Workflow, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(
+		`SELECT `+workflowColumns+`
+		 FROM workflows w JOIN workflow_revisions r ON r.workflow_id = w.id AND r.revision = w.revision
+		 WHERE w.id = ?`), id)
+	return scanWorkflow(row)
+}
+
+// GetWorkflowRevision implements Store.
+func (s *sqlStore) GetWorkflowRevision(ctx context.Context, id string, revision int) (*Workflow, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(
+		`SELECT `+workflowColumns+`
+		 FROM workflows w JOIN workflow_revisions r ON r.workflow_id = w.id
+		 WHERE w.id = ? AND r.revision = ?`), id, revision)
+	return scanWorkflow(row)
+}
+
+// ListWorkflows implements Store.
+func (s *sqlStore) ListWorkflows(ctx context.Context, offset, limit int) ([]*Workflow, int64, error) {
+	var total int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM workflows`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count workflows: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT `+workflowColumns+`
+		 FROM workflows w JOIN workflow_revisions r ON r.workflow_id = w.id AND r.revision = w.revision
+		 ORDER BY w.created_at DESC, w.id
+		 LIMIT ? OFFSET ?`), limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	workflows, err := scanWorkflows(rows)
+	return workflows, total, err
+}
+
+// ListWorkflowRevisions implements Store.
+func (s *sqlStore) ListWorkflowRevisions(ctx context.Context, id string) ([]*Workflow, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT `+workflowColumns+`
+		 FROM workflows w JOIN workflow_revisions r ON r.workflow_id = w.id
+		 WHERE w.id = ?
+		 ORDER BY r.revision DESC`), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow revisions: %w", err)
+	}
+	return scanWorkflows(rows)
+}
+
+// DeleteWorkflow implements Store.
+func (s *sqlStore) DeleteWorkflow(ctx context.Context, id string) (bool, error) {
+	var deleted bool
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.exec(ctx, tx, `DELETE FROM workflow_revisions WHERE workflow_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete workflow revisions: %w", err)
+		}
+		res, err := s.exec(ctx, tx, `DELETE FROM workflows WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete workflow: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		deleted = err == nil && affected > 0
+		return nil
+	})
+	return deleted, err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWorkflow(row rowScanner) (*Workflow, error) {
+	var wf Workflow
+	var graph string
+	err := row.Scan(&wf.ID, &wf.Revision, &wf.Name, &wf.Description, &wf.Priority, &graph, &wf.RestoredFrom, &wf.CreatedAt, &wf.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+	wf.Graph = json.RawMessage(graph)
+	return &wf, nil
+}
+
+func scanWorkflows(rows *sql.Rows) ([]*Workflow, error) {
+	defer rows.Close()
+
+	workflows := make([]*Workflow, 0)
+	for rows.Next() {
+		wf, err := scanWorkflow(rows)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, wf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read workflows: %w", err)
+	}
+	return workflows, nil
+}
+
+// --- Runs ---
+
+const runColumns = `id, workflow_id, revision, status, trigger_kind, trigger_node_id, priority, created_at, queued_at, started_at, ended_at, duration_ms, error, retry_of, retry_from, input, mode`
+
+// SaveRun implements Store.
+// Lineage, input, mode and graph are set when the run is created and never updated.
+func (s *sqlStore) SaveRun(ctx context.Context, run *Run) error {
+	_, err := s.exec(ctx, s.db,
+		`INSERT INTO runs (`+runColumns+`, graph) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status,
+			queued_at = excluded.queued_at,
+			started_at = excluded.started_at,
+			ended_at = excluded.ended_at,
+			duration_ms = excluded.duration_ms,
+			error = excluded.error`,
+		run.ID, run.WorkflowID, run.Revision, run.Status, run.Trigger, run.TriggerNodeID, run.Priority,
+		run.CreatedAt, run.QueuedAt, run.StartedAt, run.EndedAt, run.DurationMs, run.Error,
+		run.RetryOf, run.RetryFrom, string(run.Input), run.Mode, string(run.Graph))
+	if err != nil {
+		return fmt.Errorf("failed to save run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// InsertRun implements Store.
+func (s *sqlStore) InsertRun(ctx context.Context, run *Run) (bool, error) {
+	res, err := s.exec(ctx, s.db,
+		`INSERT INTO runs (`+runColumns+`, graph) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (id) DO NOTHING`,
+		run.ID, run.WorkflowID, run.Revision, run.Status, run.Trigger, run.TriggerNodeID, run.Priority,
+		run.CreatedAt, run.QueuedAt, run.StartedAt, run.EndedAt, run.DurationMs, run.Error,
+		run.RetryOf, run.RetryFrom, string(run.Input), run.Mode, string(run.Graph))
+	if err != nil {
+		return false, fmt.Errorf("failed to insert run %s: %w", run.ID, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to insert run %s: %w", run.ID, err)
+	}
+	return affected > 0, nil
+}
+
+// UpdateRun implements Store.
+func (s *sqlStore) UpdateRun(ctx context.Context, run *Run, status string) (bool, error) {
+	res, err := s.exec(ctx, s.db,
+		`UPDATE runs SET status = ?, queued_at = ?, started_at = ?, ended_at = ?, duration_ms = ?, error = ?
+		 WHERE id = ? AND status = ?`,
+		run.Status, run.QueuedAt, run.StartedAt, run.EndedAt, run.DurationMs, run.Error,
+		run.ID, status)
+	if err != nil {
+		return false, fmt.Errorf("failed to update run %s: %w", run.ID, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update run %s: %w", run.ID, err)
+	}
+	return affected > 0, nil
+}
+
+// GetRun implements Store.
+func (s *sqlStore) GetRun(ctx context.Context, id string) (*Run, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+runColumns+` FROM runs WHERE id = ?`), id)
+	return scanRun(row)
+}
+
+// GetRunGraph implements Store.
+func (s *sqlStore) GetRunGraph(ctx context.Context, id string) (json.RawMessage, error) {
+	var graph string
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT graph FROM runs WHERE id = ?`), id).Scan(&graph)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run graph: %w", err)
+	}
+	if graph == "" {
+		return nil, nil
+	}
+	return json.RawMessage(graph), nil
+}
+
+// ListRuns implements Store.
+func (s *sqlStore) ListRuns(ctx context.Context, workflowID string, offset, limit int) ([]*Run, int64, error) {
+	var total int64
+	if err := s.db.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM runs WHERE workflow_id = ?`), workflowID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count runs: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT `+runColumns+` FROM runs WHERE workflow_id = ?
+		 ORDER BY created_at DESC, id
+		 LIMIT ? OFFSET ?`), workflowID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]*Run, 0)
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+func scanRun(row rowScanner) (*Run, error) {
+	var run Run
+	var input string
+	err := row.Scan(&run.ID, &run.WorkflowID, &run.Revision, &run.Status, &run.Trigger, &run.TriggerNodeID, &run.Priority,
+		&run.CreatedAt, &run.QueuedAt, &run.StartedAt, &run.EndedAt, &run.DurationMs, &run.Error,
+		&run.RetryOf, &run.RetryFrom, &input, &run.Mode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run: %w", err)
+	}
+	if input != "" {
+		run.Input = json.RawMessage(input)
+	}
+	return &run, nil
+}
+
+// --- Node results ---
+
+// SaveNodeResults implements Store.
+func (s *sqlStore) SaveNodeResults(ctx context.Context, runID string, results []NodeResult) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.exec(ctx, tx, `DELETE FROM node_results WHERE run_id = ?`, runID); err != nil {
+			return fmt.Errorf("failed to clear node results: %w", err)
+		}
+
+		for i, result := range results {
+			output := result.Result
+			if len(output) == 0 {
+				output = json.RawMessage(`null`)
+			}
+			logs, err := json.Marshal(result.Logs)
+			if err != nil {
+				return fmt.Errorf("failed to marshal node logs: %w", err)
+			}
+
+			_, err = s.exec(ctx, tx,
+				`INSERT INTO node_results (run_id, seq, node_id, node_type, status, started_at, duration_ms, result, error, logs, branch)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				runID, i, result.NodeID, result.NodeType, result.Status, result.StartedAt, result.DurationMs,
+				string(output), result.Error, string(logs), result.Branch)
+			if err != nil {
+				return fmt.Errorf("failed to insert node result: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListNodeResults implements Store.
+func (s *sqlStore) ListNodeResults(ctx context.Context, runID string) ([]NodeResult, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT run_id, seq, node_id, node_type, status, started_at, duration_ms, result, error, logs, branch
+		 FROM node_results WHERE run_id = ? ORDER BY seq`), runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node results: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]NodeResult, 0)
+	for rows.Next() {
+		var result NodeResult
+		var output, logs string
+		if err := rows.Scan(&result.RunID, &result.Position, &result.NodeID, &result.NodeType, &result.Status,
+			&result.StartedAt, &result.DurationMs, &output, &result.Error, &logs, &result.Branch); err != nil {
+			return nil, fmt.Errorf("failed to read node result: %w", err)
+		}
+		result.Result = json.RawMessage(output)
+		if err := json.Unmarshal([]byte(logs), &result.Logs); err != nil {
+			return nil, fmt.Errorf("failed to decode node logs: %w", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read node results: %w", err)
+	}
+	return results, nil
+}
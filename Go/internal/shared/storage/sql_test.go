@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"XKA/pkg/logger"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func openTestStore(t *testing.T) Store {
+	t.Helper()
+	store, err := Open(context.Background(), Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "store.db")})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func testRun(id, status string) *Run {
+	return &Run{ID: id, WorkflowID: "wf", Status: status, Trigger: "manual", CreatedAt: 1, Mode: "production"}
+}
+
+func TestInsertRun(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	inserted, err := store.InsertRun(ctx, testRun("run-1", "queued"))
+	if err != nil || !inserted {
+		t.Fatalf("InsertRun() = %v, %v, want true", inserted, err)
+	}
+
+	// An existing run is never overwritten
+	if err := store.SaveRun(ctx, testRun("run-1", "cancelled")); err != nil {
+		t.Fatalf("SaveRun() error = %v", err)
+	}
+	inserted, err = store.InsertRun(ctx, testRun("run-1", "running"))
+	if err != nil || inserted {
+		t.Fatalf("InsertRun() of an existing run = %v, %v, want false", inserted, err)
+	}
+	got, err := store.GetRun(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("GetRun() error = %v", err)
+	}
+	if got.Status != "cancelled" {
+		t.Errorf("status = %s, want cancelled", got.Status)
+	}
+}
+
+func TestUpdateRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		expected string
+		updated  bool
+		want     string
+	}{
+		{"expected status", "queued", "queued", true, "running"},
+		{"status changed meanwhile", "cancelled", "queued", false, "cancelled"},
+		{"missing run", "", "queued", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := openTestStore(t)
+			if tt.stored != "" {
+				if err := store.SaveRun(ctx, testRun("run-1", tt.stored)); err != nil {
+					t.Fatalf("SaveRun() error = %v", err)
+				}
+			}
+
+			change := testRun("run-1", "running")
+			change.StartedAt = 10
+			change.Error = "changed"
+			updated, err := store.UpdateRun(ctx, change, tt.expected)
+			if err != nil || updated != tt.updated {
+				t.Fatalf("UpdateRun() = %v, %v, want %v", updated, err, tt.updated)
+			}
+
+			got, err := store.GetRun(ctx, "run-1")
+			if tt.stored == "" {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("GetRun() error = %v, want %v", err, ErrNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRun() error = %v", err)
+			}
+			if got.Status != tt.want {
+				t.Errorf("status = %s, want %s", got.Status, tt.want)
+			}
+			if tt.updated && (got.StartedAt != 10 || got.Error != "changed") {
+				t.Errorf("progress not updated: %+v", got)
+			}
+		})
+	}
+}
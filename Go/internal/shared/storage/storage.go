@@ -0,0 +1,199 @@
+// Package storage is the durable persistence layer of the platform.
+// It keeps workflow definitions, their revisions, runs and node results.
+// Redis stays the job queue and a hot cache in front of the store.
+//
+// Two backends are available behind the Store interface:
+//   - sqlite: embedded database file, for single-node and development setups
+//   - postgres: shared database for production deployments
+//
+// The backend is selected with STORAGE_DRIVER (sqlite or postgres) and
+// STORAGE_DSN (a file path for sqlite, a connection URL for postgres).
+package storage
+
+import (
+	"XKA/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Supported drivers.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// DefaultSQLitePath is the database file used when no DSN is configured.
+const DefaultSQLitePath = "xka.db"
+
+// Store errors.
+var (
+	ErrNotFound = errors.New("record not found")
+	ErrExists   = errors.New("record already exists")
+)
+
+// Workflow is a revision of a stored workflow definition.
+// The latest revision is the current state of the workflow.
+type Workflow struct {
+	ID           string
+	Revision     int
+	Name         string
+	Description  string
+	Priority     string
+	Graph        json.RawMessage // {"nodes": [...], "edges": [...]}
+	RestoredFrom int             // Revision copied by a rollback, 0 otherwise
+	CreatedAt    int64           // Workflow creation (unix milliseconds)
+	UpdatedAt    int64           // Revision creation (unix milliseconds)
+}
+
+// Run is the summary of a workflow execution. Times are unix milliseconds.
+type Run struct {
+	ID            string
+	WorkflowID    string
+	Revision      int
+	Status        string
+	Trigger       string
+	TriggerNodeID string
+	Priority      string
+	CreatedAt     int64
+	QueuedAt      int64
+	StartedAt     int64
+	EndedAt       int64
+	DurationMs    int64
+	Error         string
+	Mode          string          // production or development
+	RetryOf       string          // Run this run retries, empty otherwise
+	RetryFrom     string          // Node the retry re-executes from
+	Input         json.RawMessage // Validated run input, empty if none
+	Graph         json.RawMessage // Graph of ad-hoc submissions, only written (read it with GetRunGraph)
+}
+
+// NodeResult is the outcome of one node execution within a run.
+type NodeResult struct {
+	RunID      string
+	Position   int // Execution order within the run
+	NodeID     string
+	NodeType   string
+	Status     string
+	StartedAt  int64 // Unix seconds, as reported by the runner
+	DurationMs int64
+	Result     json.RawMessage
+	Error      string
+	Logs       []string
+	Branch     string // Output taken by the node, empty for the default one
+}
+
+// Store persists workflows, revisions, runs and node results.
+type Store interface {
+	// CreateWorkflow stores the first revision of a new workflow.
+	// The revision number is assigned by the store. Fails with ErrExists if the ID is taken.
+	CreateWorkflow(ctx context.Context, wf *Workflow) error
+	// AddWorkflowRevision stores a new revision of an existing workflow and makes it the latest.
+	// The revision number is assigned by the store. Fails with ErrNotFound if the workflow does not exist.
+	AddWorkflowRevision(ctx context.Context, wf *Workflow) error
+	// GetWorkflow returns the latest revision of a workflow.
+	GetWorkflow(ctx context.Context, id string) (*Workflow, error)
+	// GetWorkflowRevision returns a given revision of a workflow.
+	GetWorkflowRevision(ctx context.Context, id string, revision int) (*Workflow, error)
+	// ListWorkflows returns the latest revision of each workflow, most recently created first, and the total count.
+	ListWorkflows(ctx context.Context, offset, limit int) ([]*Workflow, int64, error)
+	// ListWorkflowRevisions returns every revision of a workflow, most recent first.
+	ListWorkflowRevisions(ctx context.Context, id string) ([]*Workflow, error)
+	// DeleteWorkflow removes a workflow and its revisions. Runs are kept.
+	DeleteWorkflow(ctx context.Context, id string) (bool, error)
+
+	// SaveRun inserts or replaces a run.
+	SaveRun(ctx context.Context, run *Run) error
+	// InsertRun inserts a new run. Returns false, leaving the stored run untouched, if the ID is taken.
+	InsertRun(ctx context.Context, run *Run) (bool, error)
+	// UpdateRun replaces the progress of a run (status, times, error) only if the
+	// run still has the given status. Returns false if it does not.
+	UpdateRun(ctx context.Context, run *Run, status string) (bool, error)
+	// GetRun returns a run, without its graph.
+	GetRun(ctx context.Context, id string) (*Run, error)
+	// GetRunGraph returns the graph stored with an ad-hoc run, nil for runs of stored workflows.
+	GetRunGraph(ctx context.Context, id string) (json.RawMessage, error)
+	// ListRuns returns the runs of a workflow, most recent first, and the total count.
+	ListRuns(ctx context.Context, workflowID string, offset, limit int) ([]*Run, int64, error)
+
+	// SaveNodeResults replaces the node results of a run.
+	SaveNodeResults(ctx context.Context, runID string, results []NodeResult) error
+	// ListNodeResults returns the node results of a run in execution order.
+	ListNodeResults(ctx context.Context, runID string) ([]NodeResult, error)
+
+	// Close releases the underlying connections.
+	Close() error
+}
+
+// Config selects and configures a storage backend.
+type Config struct {
+	Driver string // sqlite or postgres
+	DSN    string // File path (sqlite) or connection URL (postgres)
+}
+
+// ConfigFromEnv reads STORAGE_DRIVER and STORAGE_DSN.
+// The driver defaults to postgres for postgres:// URLs and to sqlite otherwise.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Driver: strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_DRIVER"))),
+		DSN:    strings.TrimSpace(os.Getenv("STORAGE_DSN")),
+	}
+
+	if cfg.Driver == "" {
+		if strings.HasPrefix(cfg.DSN, "postgres://") || strings.HasPrefix(cfg.DSN, "postgresql://") {
+			cfg.Driver = DriverPostgres
+		} else {
+			cfg.Driver = DriverSQLite
+		}
+	}
+	if cfg.Driver == DriverSQLite && cfg.DSN == "" {
+		cfg.DSN = DefaultSQLitePath
+	}
+	return cfg
+}
+
+// Open connects to the configured backend and applies pending migrations.
+func Open(ctx context.Context, cfg Config) (Store, error) {
+	switch cfg.Driver {
+	case DriverSQLite:
+		return openSQLite(ctx, cfg.DSN)
+	case DriverPostgres:
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("STORAGE_DSN is required for the postgres driver")
+		}
+		return openPostgres(ctx, cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q (expected %s or %s)", cfg.Driver, DriverSQLite, DriverPostgres)
+	}
+}
+
+var (
+	instance Store
+	once     sync.Once
+)
+
+// GetStore returns the store configured from the environment, opened once.
+// Returns nil if the store cannot be opened; the error is logged.
+func GetStore() Store {
+	once.Do(func() {
+		cfg := ConfigFromEnv()
+		store, err := Open(context.Background(), cfg)
+		if err != nil {
+			logger.Log.Error("Failed to open storage",
+				zap.String("driver", cfg.Driver),
+				zap.Error(err),
+			)
+			return
+		}
+
+		logger.Log.Info("Storage initialized", zap.String("driver", cfg.Driver))
+		instance = store
+	})
+	return instance
+}
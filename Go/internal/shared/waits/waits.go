@@ -0,0 +1,301 @@
+// Package waits keeps the runs suspended by a node waiting for an external event
+// (human approval, callback...) and the runs paused by an operator.
+//
+// A suspended run does not hold a worker: when a run reaches such a node the
+// worker records a wait holding the job to resume, then moves on. The run is
+// resumed, on any worker, when the event is delivered through the API or when
+// the wait times out. A paused run is suspended the same way before its next
+// node and resumed by the operator. The nodes executed before the suspension
+// are restored from the job instead of being executed again.
+//
+// Waits are stored in Redis:
+//   - runs:waits, a hash of run ID -> Wait JSON
+//   - runs:waits:timeouts, a sorted set of run IDs scored by deadline (unix ms)
+//
+// A wait is resolved once: only the caller whose HDEL removed it resumes the run.
+// When pushing the job fails, the wait is recorded again so nothing is lost.
+package waits
+
+import (
+	"XKA/internal/shared/queue"
+	"XKA/internal/shared/runs"
+	"XKA/pkg/RedisClient"
+	"XKA/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Redis keys used by the suspended runs.
+const (
+	WaitsKey    = "runs:waits"          // Hash of run ID -> Wait JSON
+	TimeoutsKey = "runs:waits:timeouts" // Sorted set of run IDs scored by deadline (unix ms)
+)
+
+// Watcher defaults.
+const (
+	DefaultWatchInterval = 1 * time.Second
+	watchBatchSize       = 100
+)
+
+var (
+	// ErrNotWaiting is returned when the run is not suspended (anymore).
+	ErrNotWaiting = errors.New("run is not waiting for an event")
+	// ErrKeyMismatch is returned when the delivered correlation key is not the awaited one.
+	ErrKeyMismatch = errors.New("correlation key does not match the awaited event")
+	// ErrNotPaused is returned when resuming a run that is not paused (anymore).
+	ErrNotPaused = errors.New("run is not paused")
+)
+
+// Reasons of a suspension.
+const (
+	ReasonEvent  = "event"  // Waiting for an external event at a node
+	ReasonPaused = "paused" // Paused by an operator before a node
+)
+
+// Wait is a run suspended at a node.
+type Wait struct {
+	RunID          string     `json:"runId"`
+	WorkflowID     string     `json:"workflowId"`
+	Reason         string     `json:"reason"`                   // ReasonEvent or ReasonPaused
+	NodeID         string     `json:"nodeId"`                   // Node the run waits at (paused runs: node executed on resumption)
+	NodeType       string     `json:"nodeType"`                 // approvalNode, waitForEventNode...
+	CorrelationKey string     `json:"correlationKey,omitempty"` // Key the delivered event must carry (any when empty)
+	TimeoutAt      int64      `json:"timeoutAt,omitempty"`      // Deadline (unix ms), 0 to wait forever
+	CreatedAt      int64      `json:"createdAt"`                // Suspension time (unix ms)
+	Job            *queue.Job `json:"job"`                      // Job pushed again to resume the run
+}
+
+// Suspend records a wait and marks its run as waiting (or paused).
+func Suspend(client *RedisClient.Client, wait *Wait) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	if wait == nil || wait.Job == nil {
+		return fmt.Errorf("wait must have a job")
+	}
+	if wait.Reason == "" {
+		wait.Reason = ReasonEvent
+	}
+	if wait.CreatedAt == 0 {
+		wait.CreatedAt = time.Now().UnixMilli()
+	}
+
+	data, err := json.Marshal(wait)
+	if err != nil {
+		return fmt.Errorf("failed to marshal wait: %w", err)
+	}
+
+	// Store the wait first so the watcher never sees a deadline without data
+	if err := client.HSet(WaitsKey, wait.RunID, string(data)); err != nil {
+		return err
+	}
+	if wait.TimeoutAt > 0 {
+		if err := client.ZAdd(TimeoutsKey, float64(wait.TimeoutAt), wait.RunID); err != nil {
+			client.HDel(WaitsKey, wait.RunID)
+			return err
+		}
+	}
+	status := runs.StatusWaiting
+	if wait.Reason == ReasonPaused {
+		status = runs.StatusPaused
+	}
+	if err := runs.Suspend(client, wait.RunID, status); err != nil {
+		// A run finished meanwhile (e.g. cancelled) is never resumed
+		if errors.Is(err, runs.ErrFinished) {
+			claim(client, wait.RunID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns the wait of a suspended run.
+func Get(client *RedisClient.Client, runID string) (*Wait, error) {
+	raw, err := client.HGet(WaitsKey, runID)
+	if err != nil {
+		return nil, ErrNotWaiting
+	}
+
+	var wait Wait
+	if err := json.Unmarshal([]byte(raw), &wait); err != nil {
+		return nil, fmt.Errorf("failed to decode wait: %w", err)
+	}
+	if wait.Job == nil {
+		return nil, fmt.Errorf("wait of run %s has no job", runID)
+	}
+	return &wait, nil
+}
+
+// Deliver resolves the wait of a run with an event. key must match the
+// correlation key of the wait when it has one.
+func Deliver(client *RedisClient.Client, runID, key string, payload json.RawMessage) (*Wait, error) {
+	wait, err := Get(client, runID)
+	if err != nil {
+		return nil, err
+	}
+	if wait.Reason == ReasonPaused {
+		return nil, ErrNotWaiting
+	}
+	if wait.CorrelationKey != "" && key != wait.CorrelationKey {
+		return nil, ErrKeyMismatch
+	}
+	if err := resolve(client, wait, eventResolution(wait, queue.OutcomeEvent, payload), ErrNotWaiting); err != nil {
+		return nil, err
+	}
+	return wait, nil
+}
+
+// Resume pushes the job of a paused run again. The run goes on with the node
+// it was paused before; the caller must have cleared the pause command first.
+func Resume(client *RedisClient.Client, runID string) (*Wait, error) {
+	wait, err := Get(client, runID)
+	if err != nil || wait.Reason != ReasonPaused {
+		return nil, ErrNotPaused
+	}
+	// A wait resolved right before the pause keeps its outcome (see Wait.Job)
+	if err := resolve(client, wait, wait.Job.Resume, ErrNotPaused); err != nil {
+		return nil, err
+	}
+	return wait, nil
+}
+
+// Cancel drops the wait of a run and marks the run as cancelled.
+func Cancel(client *RedisClient.Client, runID string) (bool, error) {
+	claimed, err := claim(client, runID)
+	if err != nil || !claimed {
+		return false, err
+	}
+	if err := runs.Cancel(client, runID); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// claim removes a wait. Returns false if another caller resolved it first.
+func claim(client *RedisClient.Client, runID string) (bool, error) {
+	removed, err := client.HDel(WaitsKey, runID)
+	if err != nil {
+		return false, err
+	}
+	if removed == 0 {
+		return false, nil
+	}
+	if _, err := client.ZRem(TimeoutsKey, runID); err != nil {
+		logger.Log.Warn("Failed to drop wait deadline", zap.String("run_id", runID), zap.Error(err))
+	}
+	return true, nil
+}
+
+// eventResolution builds the outcome of the wait of a node.
+func eventResolution(wait *Wait, outcome string, payload json.RawMessage) *queue.Resolution {
+	return &queue.Resolution{
+		NodeID:     wait.NodeID,
+		Outcome:    outcome,
+		Payload:    payload,
+		ReceivedAt: time.Now().UnixMilli(),
+	}
+}
+
+// resolve claims a wait and pushes its job again with the given wait outcome.
+// gone is returned when another caller resolved the wait first.
+func resolve(client *RedisClient.Client, wait *Wait, resolution *queue.Resolution, gone error) error {
+	claimed, err := claim(client, wait.RunID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return gone
+	}
+
+	job := *wait.Job
+	job.EnqueuedAt = 0
+	job.Resumed = true
+	job.Resume = resolution
+	if err := runs.Enqueue(client, &job); err != nil {
+		// Put the wait back so the run can still be resumed (or time out) later
+		if restoreErr := Suspend(client, wait); restoreErr != nil {
+			logger.Log.Error("Failed to restore wait after a failed resumption",
+				zap.String("run_id", wait.RunID), zap.Error(restoreErr))
+		}
+		return fmt.Errorf("failed to resume run: %w", err)
+	}
+	return nil
+}
+
+// Watcher resumes the suspended runs whose wait timed out.
+// Several manager replicas may run a watcher concurrently: a wait is only
+// resolved by the replica whose HDEL actually removed it.
+type Watcher struct {
+	client   *RedisClient.Client
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+// NewWatcher creates a watcher polling the wait deadlines at the given interval.
+func NewWatcher(client *RedisClient.Client, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &Watcher{
+		client:   client,
+		logger:   logger.Log,
+		interval: interval,
+	}
+}
+
+// Run resumes timed out runs until the context is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("Wait timeout watcher started", zap.Duration("interval", w.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Wait timeout watcher stopped")
+			return
+		case <-ticker.C:
+			if err := w.timeoutDue(time.Now()); err != nil {
+				w.logger.Error("Failed to resume timed out runs", zap.Error(err))
+			}
+		}
+	}
+}
+
+// timeoutDue resumes every run whose deadline is not after now.
+func (w *Watcher) timeoutDue(now time.Time) error {
+	due, err := w.client.ZRangeByScore(TimeoutsKey, "-inf", strconv.FormatInt(now.UnixMilli(), 10), watchBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, runID := range due {
+		wait, err := Get(w.client, runID)
+		if err != nil {
+			// Resolved meanwhile: only the stale deadline is left
+			w.client.ZRem(TimeoutsKey, runID)
+			continue
+		}
+
+		if err := resolve(w.client, wait, eventResolution(wait, queue.OutcomeTimeout, nil), ErrNotWaiting); err != nil {
+			if !errors.Is(err, ErrNotWaiting) {
+				w.logger.Error("Failed to resume timed out run", zap.String("run_id", runID), zap.Error(err))
+			}
+			continue
+		}
+
+		w.logger.Info("Wait timed out, run resumed",
+			zap.String("run_id", runID),
+			zap.String("node_id", wait.NodeID),
+			zap.Int64("late_ms", now.UnixMilli()-wait.TimeoutAt),
+		)
+	}
+	return nil
+}
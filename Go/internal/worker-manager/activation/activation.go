@@ -0,0 +1,161 @@
+// Package activation stores the workflows whose automatic triggers are live.
+// An activated workflow is started by the worker manager itself (cron ticks,
+// incoming webhooks...) instead of by an explicit submission.
+package activation
+
+import (
+	"XKA/internal/shared/builder"
+	"XKA/internal/shared/nodetypes"
+	"XKA/internal/shared/queue"
+	"XKA/pkg/RedisClient"
+	"XKA/pkg/ids"
+	"XKA/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ActiveWorkflowsKey is the Redis hash of workflow ID -> Activation JSON.
+const ActiveWorkflowsKey = "workflows:active"
+
+// Trigger is an automatic start node of an activated workflow.
+type Trigger struct {
+	NodeID string                 `json:"nodeId"` // Start node ID in the workflow graph
+	Type   string                 `json:"type"`   // Start node type (e.g. cronTriggerNode)
+	Data   map[string]interface{} `json:"data"`   // Start node configuration
+}
+
+// Activation is a built workflow registered for automatic execution.
+type Activation struct {
+	WorkflowID  string          `json:"workflowId"`  // Activated workflow ID
+	Workflow    json.RawMessage `json:"workflow"`    // Serialized builder.Workflow run on each trigger
+	Triggers    []Trigger       `json:"triggers"`    // Automatic triggers of the workflow
+	Priority    queue.Priority  `json:"priority"`    // Priority of the runs started by the triggers
+	ActivatedAt int64           `json:"activatedAt"` // Activation time (unix milliseconds)
+}
+
+// New builds an activation from an initialized workflow.
+// Every trigger except manual ones is activated. Activated workflows run in
+// production: pinned node outputs are left out of the stored graph.
+func New(workflow *builder.Workflow, priority queue.Priority) (*Activation, error) {
+	if workflow == nil {
+		return nil, fmt.Errorf("workflow cannot be nil")
+	}
+	workflow = workflow.WithoutPinnedData()
+
+	triggers := make([]Trigger, 0, len(workflow.StartNodeIDs))
+	for _, node := range workflow.GetStartNodes() {
+		if nodetypes.TriggerKindOf(node.Type) == nodetypes.TriggerManual {
+			continue
+		}
+		triggers = append(triggers, Trigger{
+			NodeID: node.ID,
+			Type:   node.Type,
+			Data:   node.Data,
+		})
+	}
+
+	if len(triggers) == 0 {
+		return nil, fmt.Errorf("workflow %s has no automatic trigger to activate", workflow.ID)
+	}
+
+	data, err := json.Marshal(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workflow: %w", err)
+	}
+
+	return &Activation{
+		WorkflowID:  workflow.ID,
+		Workflow:    data,
+		Triggers:    triggers,
+		Priority:    priority,
+		ActivatedAt: time.Now().UnixMilli(),
+	}, nil
+}
+
+// TriggersOfType returns the triggers of the given node type.
+func (a *Activation) TriggersOfType(nodeType string) []Trigger {
+	triggers := make([]Trigger, 0, len(a.Triggers))
+	for _, trigger := range a.Triggers {
+		if trigger.Type == nodeType {
+			triggers = append(triggers, trigger)
+		}
+	}
+	return triggers
+}
+
+// NewJob creates the execution job of a new run for a trigger firing with the given input.
+func (a *Activation) NewJob(trigger Trigger, input map[string]interface{}) *queue.Job {
+	return &queue.Job{
+		RunID:         ids.New("run"),
+		WorkflowID:    a.WorkflowID,
+		Priority:      a.Priority,
+		Workflow:      a.Workflow,
+		Trigger:       string(nodetypes.TriggerKindOf(trigger.Type)),
+		TriggerNodeID: trigger.NodeID,
+		Input:         input,
+	}
+}
+
+// Save registers (or replaces) an activation.
+func Save(client *RedisClient.Client, activation *Activation) error {
+	if client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+
+	data, err := json.Marshal(activation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal activation: %w", err)
+	}
+	return client.HSet(ActiveWorkflowsKey, activation.WorkflowID, string(data))
+}
+
+// Get returns the activation of a workflow.
+func Get(client *RedisClient.Client, workflowID string) (*Activation, error) {
+	raw, err := client.HGet(ActiveWorkflowsKey, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	return decode(raw)
+}
+
+// List returns every activation ordered by activation time.
+func List(client *RedisClient.Client) ([]*Activation, error) {
+	entries, err := client.HGetAll(ActiveWorkflowsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	activations := make([]*Activation, 0, len(entries))
+	for id, raw := range entries {
+		activation, err := decode(raw)
+		if err != nil {
+			logger.Log.Warn("Skipping corrupted activation", zap.String("workflow_id", id), zap.Error(err))
+			continue
+		}
+		activations = append(activations, activation)
+	}
+
+	sort.Slice(activations, func(i, j int) bool { return activations[i].ActivatedAt < activations[j].ActivatedAt })
+	return activations, nil
+}
+
+// Delete deactivates a workflow. Returns false if it was not active.
+func Delete(client *RedisClient.Client, workflowID string) (bool, error) {
+	removed, err := client.HDel(ActiveWorkflowsKey, workflowID)
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+func decode(raw string) (*Activation, error) {
+	var activation Activation
+	if err := json.Unmarshal([]byte(raw), &activation); err != nil {
+		return nil, fmt.Errorf("failed to decode activation: %w", err)
+	}
+	return &activation, nil
+}
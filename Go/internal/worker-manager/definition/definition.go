@@ -0,0 +1,270 @@
+// Package definition stores workflow definitions as first-class resources.
+// A stored definition can be run any number of times without resending its graph.
+// Every save creates an immutable, numbered revision; the definition itself is
+// the latest revision and any previous one can still be run or restored.
+// Definitions live in the durable store (see package storage).
+package definition
+
+import (
+	"XKA/internal/shared/queue"
+	"XKA/internal/shared/storage"
+	"XKA/internal/worker-manager/parser"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Store errors.
+var (
+	ErrNotFound = errors.New("workflow not found")
+	ErrExists   = errors.New("workflow already exists")
+
+	ErrRevisionNotFound = errors.New("workflow revision not found")
+)
+
+// Definition is a stored workflow graph.
+type Definition struct {
+	ID           string           `json:"id"`
+	Revision     int              `json:"revision"` // Revision number, starting at 1
+	Name         string           `json:"name,omitempty"`
+	Description  string           `json:"description,omitempty"`
+	Priority     queue.Priority   `json:"priority"` // Default priority of its runs
+	Nodes        []parser.RawNode `json:"nodes"`
+	Edges        []parser.RawEdge `json:"edges"`
+	RestoredFrom int              `json:"restoredFrom,omitempty"` // Revision copied by a rollback
+	CreatedAt    int64            `json:"createdAt"`              // Workflow creation (unix milliseconds)
+	UpdatedAt    int64            `json:"updatedAt"`              // Revision creation (unix milliseconds)
+}
+
+// Summary is the listing view of a definition.
+type Summary struct {
+	ID           string `json:"id"`
+	Revision     int    `json:"revision"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+	NodeCount    int    `json:"nodeCount"`
+	EdgeCount    int    `json:"edgeCount"`
+	RestoredFrom int    `json:"restoredFrom,omitempty"`
+	CreatedAt    int64  `json:"createdAt"`
+	UpdatedAt    int64  `json:"updatedAt"`
+}
+
+// Summary returns the listing view of the definition.
+func (d *Definition) Summary() Summary {
+	return Summary{
+		ID:           d.ID,
+		Revision:     d.Revision,
+		Name:         d.Name,
+		Description:  d.Description,
+		NodeCount:    len(d.Nodes),
+		EdgeCount:    len(d.Edges),
+		RestoredFrom: d.RestoredFrom,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+	}
+}
+
+// Graph returns the parsed graph of the definition, ready for builder.InitWorkflow.
+func (d *Definition) Graph() *parser.Payload {
+	return &parser.Payload{Nodes: d.Nodes, Edges: d.Edges}
+}
+
+// ParseRevision reads a revision number given as a JSON number or a string.
+func ParseRevision(raw interface{}) (int, error) {
+	var revision int
+	switch v := raw.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("revision must be an integer")
+		}
+		revision = int(v)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid revision %q", v)
+		}
+		revision = n
+	default:
+		return 0, fmt.Errorf("revision must be a number")
+	}
+
+	if revision < 1 {
+		return 0, fmt.Errorf("revision must be greater than 0")
+	}
+	return revision, nil
+}
+
+// Create stores a new definition as its first revision. Fails with ErrExists if the ID is taken.
+func Create(ctx context.Context, store storage.Store, def *Definition) error {
+	record, err := def.toRecord()
+	if err != nil {
+		return err
+	}
+
+	if err := store.CreateWorkflow(ctx, record); err != nil {
+		if errors.Is(err, storage.ErrExists) {
+			return ErrExists
+		}
+		return err
+	}
+	def.setVersion(record)
+	return nil
+}
+
+// Update stores a new revision of an existing definition.
+// Earlier revisions are left untouched.
+func Update(ctx context.Context, store storage.Store, def *Definition) error {
+	record, err := def.toRecord()
+	if err != nil {
+		return err
+	}
+
+	if err := store.AddWorkflowRevision(ctx, record); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	def.setVersion(record)
+	return nil
+}
+
+// Rollback stores a copy of a previous revision as the new latest revision.
+func Rollback(ctx context.Context, store storage.Store, id string, revision int) (*Definition, error) {
+	def, err := GetRevision(ctx, store, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	def.RestoredFrom = revision
+	if err := Update(ctx, store, def); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// Get returns the latest revision of a stored definition.
+func Get(ctx context.Context, store storage.Store, id string) (*Definition, error) {
+	record, err := store.GetWorkflow(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return fromRecord(record)
+}
+
+// GetRevision returns the snapshot of a definition at the given revision.
+func GetRevision(ctx context.Context, store storage.Store, id string, revision int) (*Definition, error) {
+	record, err := store.GetWorkflowRevision(ctx, id, revision)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			if _, getErr := Get(ctx, store, id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return fromRecord(record)
+}
+
+// ListRevisions returns the revisions of a definition, most recent first.
+func ListRevisions(ctx context.Context, store storage.Store, id string) ([]Summary, error) {
+	records, err := store.ListWorkflowRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrNotFound // A stored workflow always has at least one revision
+	}
+	return summaries(records)
+}
+
+// List returns definition summaries, most recently created first, and the total count.
+func List(ctx context.Context, store storage.Store, offset, limit int) ([]Summary, int64, error) {
+	records, total, err := store.ListWorkflows(ctx, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list, err := summaries(records)
+	return list, total, err
+}
+
+// Delete removes a definition and its revisions. Returns false if it did not exist.
+// Runs of the workflow are kept.
+func Delete(ctx context.Context, store storage.Store, id string) (bool, error) {
+	return store.DeleteWorkflow(ctx, id)
+}
+
+// graph is the stored form of the nodes and edges of a definition.
+type graph struct {
+	Nodes []parser.RawNode `json:"nodes"`
+	Edges []parser.RawEdge `json:"edges"`
+}
+
+// toRecord converts a definition to its storage representation.
+func (d *Definition) toRecord() (*storage.Workflow, error) {
+	data, err := json.Marshal(graph{Nodes: d.Nodes, Edges: d.Edges})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workflow graph: %w", err)
+	}
+
+	return &storage.Workflow{
+		ID:           d.ID,
+		Name:         d.Name,
+		Description:  d.Description,
+		Priority:     d.Priority.String(),
+		Graph:        data,
+		RestoredFrom: d.RestoredFrom,
+	}, nil
+}
+
+// setVersion copies the revision number and times assigned by the store.
+func (d *Definition) setVersion(record *storage.Workflow) {
+	d.Revision = record.Revision
+	d.CreatedAt = record.CreatedAt
+	d.UpdatedAt = record.UpdatedAt
+}
+
+// fromRecord converts a stored workflow revision to a definition.
+func fromRecord(record *storage.Workflow) (*Definition, error) {
+	var g graph
+	if err := json.Unmarshal(record.Graph, &g); err != nil {
+		return nil, fmt.Errorf("failed to decode workflow %s graph: %w", record.ID, err)
+	}
+
+	priority, err := queue.ParsePriority(record.Priority)
+	if err != nil {
+		priority = queue.PriorityNormal
+	}
+
+	def := &Definition{
+		ID:           record.ID,
+		Name:         record.Name,
+		Description:  record.Description,
+		Priority:     priority,
+		Nodes:        g.Nodes,
+		Edges:        g.Edges,
+		RestoredFrom: record.RestoredFrom,
+	}
+	def.setVersion(record)
+	return def, nil
+}
+
+func summaries(records []*storage.Workflow) ([]Summary, error) {
+	list := make([]Summary, 0, len(records))
+	for _, record := range records {
+		def, err := fromRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, def.Summary())
+	}
+	return list, nil
+}
@@ -0,0 +1,74 @@
+// Package event starts the activated workflows listening for a published event.
+package event
+
+import (
+	"XKA/internal/shared/nodetypes"
+	"XKA/internal/shared/runs"
+	"XKA/internal/worker-manager/activation"
+	"XKA/pkg/RedisClient"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TriggerNodeType is the node type started by published events.
+const TriggerNodeType = nodetypes.EventTriggerNode
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+// Dispatched is a run started by a published event.
+type Dispatched struct {
+	WorkflowID string `json:"workflowId"`
+	NodeID     string `json:"nodeId"`
+	RunID      string `json:"runId"`
+}
+
+// ParseName reads and validates the event name an eventTriggerNode listens to.
+// Expected data: {"event": "order.created"}.
+func ParseName(data map[string]interface{}) (string, error) {
+	name, _ := data["event"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("missing 'event' parameter")
+	}
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid event name %q: only letters, digits, '.', '_', ':' and '-' are allowed", name)
+	}
+	return name, nil
+}
+
+// Dispatch enqueues a run for every active trigger listening to the event.
+func Dispatch(client *RedisClient.Client, name string, payload interface{}) ([]Dispatched, error) {
+	activations, err := activation.List(client)
+	if err != nil {
+		return nil, err
+	}
+
+	input := map[string]interface{}{
+		"event":      name,
+		"payload":    payload,
+		"receivedAt": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	dispatched := make([]Dispatched, 0)
+	for _, act := range activations {
+		for _, trigger := range act.TriggersOfType(TriggerNodeType) {
+			listening, err := ParseName(trigger.Data)
+			if err != nil || listening != name {
+				continue
+			}
+
+			job := act.NewJob(trigger, input)
+			if err := runs.Enqueue(client, job); err != nil {
+				return dispatched, fmt.Errorf("failed to start workflow %s: %w", act.WorkflowID, err)
+			}
+			dispatched = append(dispatched, Dispatched{
+				WorkflowID: act.WorkflowID,
+				NodeID:     trigger.NodeID,
+				RunID:      job.RunID,
+			})
+		}
+	}
+	return dispatched, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"XKA/internal/shared/nodetypes"
 	"XKA/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -23,105 +24,143 @@ type RawNode struct {
 	ID   string                 `json:"id" validate:"required"`   // Unique identifier for the node
 	Type string                 `json:"type" validate:"required"` // Node type (e.g., "start", "process", "end")
 	Data map[string]interface{} `json:"data"`                     // Node-specific configuration and parameters
+
+	index int // Position in the submitted nodes array, for issue pointers while parsing
 }
 
 // RawEdge represents a connection between two nodes as received from JSON.
 // Defines the flow direction and relationship between workflow nodes.
 // Source and Target must reference existing node IDs for valid connections.
 type RawEdge struct {
-	ID     string      `json:"id" validate:"required"`     // Unique identifier for the edge
-	Source string      `json:"source" validate:"required"` // ID of the source node
-	Target string      `json:"target" validate:"required"` // ID of the target node
-	Type   interface{} `json:"type"`                       // Edge type (optional, can be null)
+	ID           string      `json:"id" validate:"required"`     // Unique identifier for the edge
+	Source       string      `json:"source" validate:"required"` // ID of the source node
+	Target       string      `json:"target" validate:"required"` // ID of the target node
+	SourceHandle string      `json:"sourceHandle,omitempty"`     // Output of the source node the edge leaves from (optional)
+	Type         interface{} `json:"type"`                       // Edge type (optional, can be null)
+
+	index int // Position in the submitted edges array, for issue pointers while parsing
 }
 
-// WorkflowParseError represents parsing errors with contextual information
-type WorkflowParseError struct {
-	Field   string // The field that caused the error
-	Index   int    // Index in array if applicable (-1 if not applicable)
-	Message string // Error description
+// Issue severities.
+const (
+	SeverityError   = "error"   // The workflow is rejected
+	SeverityWarning = "warning" // The workflow is accepted; the issue is reported
+)
+
+// Issue codes.
+const (
+	CodeRequired      = "required"       // Missing or empty field
+	CodeInvalidType   = "invalid_type"   // Field of the wrong JSON type
+	CodeDuplicateID   = "duplicate_id"   // Node or edge ID used twice
+	CodeUnknownNode   = "unknown_node"   // Edge referencing a node that does not exist
+	CodeNoTrigger     = "no_trigger"     // Workflow without a trigger node
+	CodeInvalidSchema = "invalid_schema" // Invalid JSON Schema in a node's data
+	CodeCycle         = "cycle"          // Node part of a cycle (cycles are not supported)
+	CodeUnreachable   = "unreachable"    // Node no trigger leads to
+	CodeUnknownType   = "unknown_type"   // Node type missing from the registry
+	CodeInvalidConfig = "invalid_config" // Node data rejected by the configuration schema of its type
+)
+
+// Issue is a problem found while validating a workflow.
+type Issue struct {
+	Path     string `json:"path"`             // JSON pointer into the submitted workflow, e.g. /nodes/3/data/url
+	Code     string `json:"code"`             // Machine-readable code (see the Code constants)
+	Severity string `json:"severity"`         // SeverityError or SeverityWarning
+	Message  string `json:"message"`          // Human readable description
+	NodeID   string `json:"nodeId,omitempty"` // Offending node, when known
+	EdgeID   string `json:"edgeId,omitempty"` // Offending edge, when known
 }
 
-func (e *WorkflowParseError) Error() string {
-	if e.Index >= 0 {
-		return fmt.Sprintf("workflow parse error at %s[%d]: %s", e.Field, e.Index, e.Message)
-	}
-	return fmt.Sprintf("workflow parse error at %s: %s", e.Field, e.Message)
+// ValidationError reports every problem found in a workflow, not just the first.
+type ValidationError struct {
+	Issues []Issue
 }
 
-// ParseWorkflow converts raw payload map into structured Payload object.
-// This is the main entry point for workflow parsing and validation.
-// It performs comprehensive validation to ensure workflow integrity.
-func ParseWorkflow(rawPayload map[string]interface{}) (*Payload, error) {
-	if rawPayload == nil {
-		return nil, &WorkflowParseError{
-			Field:   "payload",
-			Index:   -1,
-			Message: "payload cannot be nil",
+// Error implements the error interface with the errors of the report.
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if issue.Severity != SeverityError {
+			continue
 		}
-	}
-
-	var payload Payload
-
-	// Method 1: Direct JSON marshaling for clean type conversion
-	// This approach ensures consistent data types and proper validation
-	jsonBytes, err := json.Marshal(rawPayload)
-	if err != nil {
-		return nil, &WorkflowParseError{
-			Field:   "payload",
-			Index:   -1,
-			Message: fmt.Sprintf("failed to marshal raw payload: %v", err),
+		if issue.Path == "" {
+			messages = append(messages, issue.Message)
+			continue
 		}
+		messages = append(messages, issue.Path+": "+issue.Message)
 	}
+	return "invalid workflow: " + strings.Join(messages, "; ")
+}
 
-	if err := json.Unmarshal(jsonBytes, &payload); err != nil {
-		return nil, &WorkflowParseError{
-			Field:   "payload",
-			Index:   -1,
-			Message: fmt.Sprintf("failed to unmarshal into Payload struct: %v", err),
+// HasErrors reports whether at least one issue is an error.
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
 		}
 	}
+	return false
+}
 
-	// Method 2: Manual parsing for better error handling and validation
-	// This provides more granular control over the parsing process
-	rawNodesRaw, ok := rawPayload["nodes"].([]interface{})
-	if !ok {
-		return nil, &WorkflowParseError{
-			Field:   "nodes",
-			Index:   -1,
-			Message: "field missing or not an array",
+// NodePointer returns the JSON pointer of a node of a parsed payload, or of one
+// of its fields ("data", "data/url"...). Returns "/nodes" if the node is unknown.
+// A parsed payload keeps the submitted order of the nodes.
+func (p *Payload) NodePointer(nodeID string, field string) string {
+	for i, node := range p.Nodes {
+		if node.ID != nodeID {
+			continue
+		}
+		if field == "" {
+			return fmt.Sprintf("/nodes/%d", i)
 		}
+		return fmt.Sprintf("/nodes/%d/%s", i, field)
 	}
+	return "/nodes"
+}
 
-	rawNodes, err := parseNodes(rawNodesRaw)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing nodes: %w", err)
-	}
+// issues collects the problems of a workflow being parsed.
+type issues []Issue
+
+func (l *issues) add(path, code, message, nodeID, edgeID string) {
+	*l = append(*l, Issue{
+		Path:     path,
+		Code:     code,
+		Severity: SeverityError,
+		Message:  message,
+		NodeID:   nodeID,
+		EdgeID:   edgeID,
+	})
+}
 
-	rawEdgesRaw, ok := rawPayload["edges"].([]interface{})
-	if !ok {
-		return nil, &WorkflowParseError{
-			Field:   "edges",
-			Index:   -1,
-			Message: "field missing or not an array",
-		}
+// ParseWorkflow converts raw payload map into structured Payload object.
+// This is the main entry point for workflow parsing and validation.
+// Every problem is collected: on failure the returned *ValidationError lists
+// all of them with the JSON pointer of the offending value.
+func ParseWorkflow(rawPayload map[string]interface{}) (*Payload, error) {
+	if rawPayload == nil {
+		return nil, &ValidationError{Issues: []Issue{{
+			Path:     "",
+			Code:     CodeRequired,
+			Severity: SeverityError,
+			Message:  "payload cannot be nil",
+		}}}
 	}
 
-	rawEdges, err := parseEdges(rawEdgesRaw)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing edges: %w", err)
-	}
+	var found issues
+
+	rawNodes := parseNodes(rawPayload["nodes"], &found)
+	rawEdges := parseEdges(rawPayload["edges"], &found)
 
-	// Create validated payload with manual parsing results
-	// This ensures consistency between both parsing methods
 	validatedPayload := &Payload{
 		Nodes: rawNodes,
 		Edges: rawEdges,
 	}
 
 	// Perform structural validation to ensure workflow integrity
-	if err := validateWorkflowStructure(validatedPayload); err != nil {
-		return nil, err
+	validateWorkflowStructure(validatedPayload, &found)
+
+	if len(found) > 0 {
+		return nil, &ValidationError{Issues: found}
 	}
 
 	// Log successful parsing with detailed information
@@ -132,9 +171,16 @@ func ParseWorkflow(rawPayload map[string]interface{}) (*Payload, error) {
 
 // parseEdges processes raw edge data with comprehensive validation.
 // Ensures all required fields are present and properly typed.
-func parseEdges(rawEdges []interface{}) ([]RawEdge, error) {
-	if rawEdges == nil {
-		return []RawEdge{}, nil // Allow empty edge arrays
+// Only the well-formed edges are returned; the others are reported.
+func parseEdges(raw interface{}, found *issues) []RawEdge {
+	rawEdges, ok := raw.([]interface{})
+	if !ok {
+		if raw == nil {
+			found.add("/edges", CodeRequired, "field missing", "", "")
+		} else {
+			found.add("/edges", CodeInvalidType, "must be an array", "", "")
+		}
+		return []RawEdge{}
 	}
 
 	edges := make([]RawEdge, 0, len(rawEdges))
@@ -142,85 +188,75 @@ func parseEdges(rawEdges []interface{}) ([]RawEdge, error) {
 	for i, raw := range rawEdges {
 		edgeMap, ok := raw.(map[string]interface{})
 		if !ok {
-			return nil, &WorkflowParseError{
-				Field:   "edges",
-				Index:   i,
-				Message: "element is not an object",
-			}
+			found.add(fmt.Sprintf("/edges/%d", i), CodeInvalidType, "element is not an object", "", "")
+			continue
 		}
 
-		edge, err := parseEdge(edgeMap, i)
-		if err != nil {
-			return nil, err
+		if edge := parseEdge(edgeMap, i, found); edge != nil {
+			edges = append(edges, *edge)
 		}
-
-		edges = append(edges, *edge)
 	}
 
-	return edges, nil
+	return edges
 }
 
 // parseEdge processes a single edge with field validation
-func parseEdge(edgeMap map[string]interface{}, index int) (*RawEdge, error) {
+func parseEdge(edgeMap map[string]interface{}, index int, found *issues) *RawEdge {
+	pointer := fmt.Sprintf("/edges/%d", index)
+	before := len(*found)
+
 	// Validate required string fields
-	id, ok := edgeMap["id"].(string)
-	if !ok || strings.TrimSpace(id) == "" {
-		return nil, &WorkflowParseError{
-			Field:   "edges",
-			Index:   index,
-			Message: "missing or invalid 'id' field",
-		}
-	}
+	id := requiredString(edgeMap, "id", pointer, "", "", found)
+	source := requiredString(edgeMap, "source", pointer, "", id, found)
+	target := requiredString(edgeMap, "target", pointer, "", id, found)
 
-	source, ok := edgeMap["source"].(string)
-	if !ok || strings.TrimSpace(source) == "" {
-		return nil, &WorkflowParseError{
-			Field:   "edges",
-			Index:   index,
-			Message: "missing or invalid 'source' field",
-		}
+	// Type field is optional and can be any type
+	var edgeType interface{}
+	if t, exists := edgeMap["type"]; exists {
+		edgeType = t
 	}
 
-	target, ok := edgeMap["target"].(string)
-	if !ok || strings.TrimSpace(target) == "" {
-		return nil, &WorkflowParseError{
-			Field:   "edges",
-			Index:   index,
-			Message: "missing or invalid 'target' field",
+	// Source handle is optional; editors omit it or send null for single-output nodes
+	var sourceHandle string
+	if h, exists := edgeMap["sourceHandle"]; exists && h != nil {
+		handle, ok := h.(string)
+		if !ok {
+			found.add(pointer+"/sourceHandle", CodeInvalidType, "must be a string", "", id)
 		}
+		sourceHandle = strings.TrimSpace(handle)
 	}
 
-	// Type field is optional and can be any type
-	var edgeType interface{}
-	if t, exists := edgeMap["type"]; exists {
-		edgeType = t
+	if len(*found) > before {
+		return nil
 	}
 
 	return &RawEdge{
-		ID:     strings.TrimSpace(id),
-		Source: strings.TrimSpace(source),
-		Target: strings.TrimSpace(target),
-		Type:   edgeType,
-	}, nil
+		ID:           id,
+		Source:       source,
+		Target:       target,
+		SourceHandle: sourceHandle,
+		Type:         edgeType,
+		index:        index,
+	}
 }
 
 // parseNodes processes raw node data with comprehensive validation.
 // Ensures all required fields are present and data integrity is maintained.
-func parseNodes(rawNodes []interface{}) ([]RawNode, error) {
-	if rawNodes == nil {
-		return nil, &WorkflowParseError{
-			Field:   "nodes",
-			Index:   -1,
-			Message: "nodes array cannot be nil",
+// Only the well-formed nodes are returned; the others are reported.
+func parseNodes(raw interface{}, found *issues) []RawNode {
+	rawNodes, ok := raw.([]interface{})
+	if !ok {
+		if raw == nil {
+			found.add("/nodes", CodeRequired, "field missing", "", "")
+		} else {
+			found.add("/nodes", CodeInvalidType, "must be an array", "", "")
 		}
+		return []RawNode{}
 	}
 
 	if len(rawNodes) == 0 {
-		return nil, &WorkflowParseError{
-			Field:   "nodes",
-			Index:   -1,
-			Message: "workflow must contain at least one node",
-		}
+		found.add("/nodes", CodeRequired, "workflow must contain at least one node", "", "")
+		return []RawNode{}
 	}
 
 	nodes := make([]RawNode, 0, len(rawNodes))
@@ -228,112 +264,119 @@ func parseNodes(rawNodes []interface{}) ([]RawNode, error) {
 	for i, raw := range rawNodes {
 		nodeMap, ok := raw.(map[string]interface{})
 		if !ok {
-			return nil, &WorkflowParseError{
-				Field:   "nodes",
-				Index:   i,
-				Message: "element is not an object",
-			}
+			found.add(fmt.Sprintf("/nodes/%d", i), CodeInvalidType, "element is not an object", "", "")
+			continue
 		}
 
-		node, err := parseNode(nodeMap, i)
-		if err != nil {
-			return nil, err
+		if node := parseNode(nodeMap, i, found); node != nil {
+			nodes = append(nodes, *node)
 		}
-
-		nodes = append(nodes, *node)
 	}
 
-	return nodes, nil
+	return nodes
 }
 
 // parseNode processes a single node with field validation
-func parseNode(nodeMap map[string]interface{}, index int) (*RawNode, error) {
-	// Validate required string fields
-	id, ok := nodeMap["id"].(string)
-	if !ok || strings.TrimSpace(id) == "" {
-		return nil, &WorkflowParseError{
-			Field:   "nodes",
-			Index:   index,
-			Message: "missing or invalid 'id' field",
-		}
-	}
+func parseNode(nodeMap map[string]interface{}, index int, found *issues) *RawNode {
+	pointer := fmt.Sprintf("/nodes/%d", index)
+	before := len(*found)
 
-	nodeType, ok := nodeMap["type"].(string)
-	if !ok || strings.TrimSpace(nodeType) == "" {
-		return nil, &WorkflowParseError{
-			Field:   "nodes",
-			Index:   index,
-			Message: "missing or invalid 'type' field",
-		}
-	}
+	// Validate required string fields
+	id := requiredString(nodeMap, "id", pointer, "", "", found)
+	nodeType := requiredString(nodeMap, "type", pointer, id, "", found)
 
 	// Data field is optional but must be an object if present
 	dataMap := make(map[string]interface{})
 	if dataRaw, exists := nodeMap["data"]; exists && dataRaw != nil {
+		var ok bool
 		if dataMap, ok = dataRaw.(map[string]interface{}); !ok {
-			return nil, &WorkflowParseError{
-				Field:   "nodes",
-				Index:   index,
-				Message: "invalid 'data' field - must be an object",
-			}
+			found.add(pointer+"/data", CodeInvalidType, "must be an object", id, "")
 		}
 	}
 
+	if len(*found) > before {
+		return nil
+	}
+
 	return &RawNode{
-		ID:   strings.TrimSpace(id),
-		Type: strings.TrimSpace(nodeType),
-		Data: dataMap,
-	}, nil
+		ID:    id,
+		Type:  nodeType,
+		Data:  dataMap,
+		index: index,
+	}
+}
+
+// requiredString reads a non-empty string field of a node or edge object,
+// reporting it at pointer/field when missing or invalid.
+func requiredString(object map[string]interface{}, field, pointer, nodeID, edgeID string, found *issues) string {
+	raw, exists := object[field]
+	if !exists || raw == nil {
+		found.add(pointer+"/"+field, CodeRequired, fmt.Sprintf("missing '%s' field", field), nodeID, edgeID)
+		return ""
+	}
+	value, ok := raw.(string)
+	if !ok {
+		found.add(pointer+"/"+field, CodeInvalidType, fmt.Sprintf("'%s' must be a string", field), nodeID, edgeID)
+		return ""
+	}
+	if strings.TrimSpace(value) == "" {
+		found.add(pointer+"/"+field, CodeRequired, fmt.Sprintf("'%s' cannot be empty", field), nodeID, edgeID)
+		return ""
+	}
+	return strings.TrimSpace(value)
 }
 
 // validateWorkflowStructure performs structural validation of the workflow.
 // Ensures node IDs are unique and all edge references are valid.
-func validateWorkflowStructure(payload *Payload) error {
+func validateWorkflowStructure(payload *Payload, found *issues) {
 	// Validate unique node IDs
 	nodeIDs := make(map[string]bool)
-	for i, node := range payload.Nodes {
+	for _, node := range payload.Nodes {
 		if nodeIDs[node.ID] {
-			return &WorkflowParseError{
-				Field:   "nodes",
-				Index:   i,
-				Message: fmt.Sprintf("duplicate node ID: %s", node.ID),
-			}
+			found.add(fmt.Sprintf("/nodes/%d/id", node.index), CodeDuplicateID, fmt.Sprintf("duplicate node ID: %s", node.ID), node.ID, "")
 		}
 		nodeIDs[node.ID] = true
 	}
 
+	// Validate node types and their configuration
+	for _, node := range payload.Nodes {
+		validateNodeConfig(node, found)
+	}
+
 	// Validate edge references
-	for i, edge := range payload.Edges {
+	for _, edge := range payload.Edges {
+		pointer := fmt.Sprintf("/edges/%d", edge.index)
 		if !nodeIDs[edge.Source] {
-			return &WorkflowParseError{
-				Field:   "edges",
-				Index:   i,
-				Message: fmt.Sprintf("source node '%s' does not exist", edge.Source),
-			}
+			found.add(pointer+"/source", CodeUnknownNode, fmt.Sprintf("source node '%s' does not exist", edge.Source), "", edge.ID)
 		}
 		if !nodeIDs[edge.Target] {
-			return &WorkflowParseError{
-				Field:   "edges",
-				Index:   i,
-				Message: fmt.Sprintf("target node '%s' does not exist", edge.Target),
-			}
+			found.add(pointer+"/target", CodeUnknownNode, fmt.Sprintf("target node '%s' does not exist", edge.Target), "", edge.ID)
 		}
 	}
 
 	// Validate unique edge IDs
 	edgeIDs := make(map[string]bool)
-	for i, edge := range payload.Edges {
+	for _, edge := range payload.Edges {
 		if edgeIDs[edge.ID] {
-			return &WorkflowParseError{
-				Field:   "edges",
-				Index:   i,
-				Message: fmt.Sprintf("duplicate edge ID: %s", edge.ID),
-			}
+			found.add(fmt.Sprintf("/edges/%d/id", edge.index), CodeDuplicateID, fmt.Sprintf("duplicate edge ID: %s", edge.ID), "", edge.ID)
 		}
 		edgeIDs[edge.ID] = true
 	}
+}
 
-	return nil
+// validateNodeConfig checks that the node type is registered and that the node
+// data matches the configuration schema of the type. Each schema error is reported
+// at its own pointer under /nodes/i/data.
+func validateNodeConfig(node RawNode, found *issues) {
+	pointer := fmt.Sprintf("/nodes/%d", node.index)
+	def, ok := nodetypes.Lookup(node.Type)
+	if !ok {
+		found.add(pointer+"/type", CodeUnknownType, fmt.Sprintf("unknown node type: %s", node.Type), node.ID, "")
+		return
+	}
+	for _, fieldErr := range def.ValidateConfig(node.Data).Prefixed(pointer + "/data") {
+		found.add(fieldErr.Path, CodeInvalidConfig, fieldErr.Message, node.ID, "")
+	}
 }
 
 // logWorkflow outputs detailed workflow information to logs.
@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"XKA/pkg/logger"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// testNodes are a valid trigger and HTTP request, as the start of a payload.
+const testNodes = `{"id": "start", "type": "manualStartNode"}, {"id": "fetch", "type": "httpRequestNode", "data": {"url": "https://example.com"}}`
+
+func TestParseWorkflow(t *testing.T) {
+	payload := decodePayload(t, `{"nodes": [`+testNodes+`], "edges": [{"id": "e1", "source": "start", "target": "fetch", "sourceHandle": null}]}`)
+
+	parsed, err := ParseWorkflow(payload)
+	if err != nil {
+		t.Fatalf("ParseWorkflow() error = %v", err)
+	}
+	if len(parsed.Nodes) != 2 || len(parsed.Edges) != 1 {
+		t.Fatalf("ParseWorkflow() = %d nodes, %d edges, want 2 and 1", len(parsed.Nodes), len(parsed.Edges))
+	}
+	if got := parsed.NodePointer("fetch", "data/url"); got != "/nodes/1/data/url" {
+		t.Errorf("NodePointer(fetch, data/url) = %s, want /nodes/1/data/url", got)
+	}
+	if got := parsed.NodePointer("missing", ""); got != "/nodes" {
+		t.Errorf("NodePointer(missing) = %s, want /nodes", got)
+	}
+}
+
+func TestParseWorkflowIssues(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    []string // Code and pointer of each issue, in report order
+	}{
+		{
+			name:    "missing fields",
+			payload: `{}`,
+			want:    []string{"required /nodes", "required /edges"},
+		},
+		{
+			name:    "invalid types",
+			payload: `{"nodes": {}, "edges": "none"}`,
+			want:    []string{"invalid_type /nodes", "invalid_type /edges"},
+		},
+		{
+			name:    "no node",
+			payload: `{"nodes": [], "edges": []}`,
+			want:    []string{"required /nodes"},
+		},
+		{
+			name:    "malformed elements",
+			payload: `{"nodes": [` + testNodes + `, "node", {"id": " ", "type": 5}], "edges": [3, {"id": "e1", "source": "start"}]}`,
+			want: []string{
+				"invalid_type /nodes/2",
+				"required /nodes/3/id", "invalid_type /nodes/3/type",
+				"invalid_type /edges/0",
+				"required /edges/1/target",
+			},
+		},
+		{
+			name:    "invalid data and handle",
+			payload: `{"nodes": [` + testNodes + `, {"id": "wait", "type": "waitingNode", "data": []}], "edges": [{"id": "e1", "source": "start", "target": "fetch", "sourceHandle": 1}]}`,
+			want:    []string{"invalid_type /nodes/2/data", "invalid_type /edges/0/sourceHandle"},
+		},
+		{
+			name:    "pointers keep the submitted positions",
+			payload: `{"nodes": [7, ` + testNodes + `, {"id": "fetch", "type": "waitingNode"}], "edges": [{"id": "e1", "source": "start", "target": "gone"}]}`,
+			want:    []string{"invalid_type /nodes/0", "duplicate_id /nodes/3/id", "unknown_node /edges/0/target"},
+		},
+		{
+			name:    "unknown node type and invalid configuration",
+			payload: `{"nodes": [` + testNodes + `, {"id": "x", "type": "teleportNode"}, {"id": "y", "type": "httpRequestNode", "data": {"url": "https://example.com", "method": "FETCH"}}], "edges": []}`,
+			want:    []string{"unknown_type /nodes/2/type", "invalid_config /nodes/3/data/method"},
+		},
+		{
+			name:    "unknown edge ends and duplicate edge",
+			payload: `{"nodes": [` + testNodes + `], "edges": [{"id": "e1", "source": "a", "target": "b"}, {"id": "e1", "source": "start", "target": "fetch"}]}`,
+			want:    []string{"unknown_node /edges/0/source", "unknown_node /edges/0/target", "duplicate_id /edges/1/id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWorkflow(decodePayload(t, tt.payload))
+
+			var validation *ValidationError
+			if !errors.As(err, &validation) {
+				t.Fatalf("ParseWorkflow() error = %v, want a validation error", err)
+			}
+			got := make([]string, 0, len(validation.Issues))
+			for _, issue := range validation.Issues {
+				if issue.Severity != SeverityError {
+					t.Errorf("issue %s %s has severity %s, want %s", issue.Code, issue.Path, issue.Severity, SeverityError)
+				}
+				got = append(got, issue.Code+" "+issue.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWorkflowIssueIDs(t *testing.T) {
+	payload := decodePayload(t, `{"nodes": [`+testNodes+`, {"id": "fetch", "type": "waitingNode"}], "edges": [{"id": "e1", "source": "start", "target": "gone"}]}`)
+
+	_, err := ParseWorkflow(payload)
+	var validation *ValidationError
+	if !errors.As(err, &validation) || len(validation.Issues) != 2 {
+		t.Fatalf("ParseWorkflow() error = %v, want 2 issues", err)
+	}
+	if issue := validation.Issues[0]; issue.NodeID != "fetch" || issue.EdgeID != "" {
+		t.Errorf("duplicate node issue = %+v, want node fetch", issue)
+	}
+	if issue := validation.Issues[1]; issue.NodeID != "" || issue.EdgeID != "e1" {
+		t.Errorf("unknown node issue = %+v, want edge e1", issue)
+	}
+}
+
+func TestParseWorkflowNilPayload(t *testing.T) {
+	var validation *ValidationError
+	if _, err := ParseWorkflow(nil); !errors.As(err, &validation) || validation.Issues[0].Code != CodeRequired {
+		t.Errorf("ParseWorkflow(nil) error = %v, want a required issue", err)
+	}
+}
+
+func decodePayload(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		t.Fatalf("invalid test payload: %v", err)
+	}
+	return payload
+}
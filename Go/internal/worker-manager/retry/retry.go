@@ -0,0 +1,115 @@
+// Package retry plans the re-execution of a finished run from one of its nodes.
+//
+// A retry is a new run of the same graph, linked to the original run. It
+// re-executes the chosen node (by default the node the run failed at) and
+// every node downstream of it. The other nodes are not executed again: the
+// responses recorded by the original run are handed to the worker and reused
+// as-is, so side effects upstream of the failure (HTTP calls, ...) happen once.
+package retry
+
+import (
+	"XKA/internal/shared/builder"
+	"encoding/json"
+	"fmt"
+)
+
+// ReusableStatuses are the node statuses whose recorded response can be reused.
+// Responses already reused by a previous retry can be reused again.
+var ReusableStatuses = map[string]bool{
+	"success": true,
+	"reused":  true,
+}
+
+// recordedNode is the part of a recorded node response needed to plan a retry.
+type recordedNode struct {
+	NodeID string `json:"nodeId"`
+	Status string `json:"status"`
+}
+
+// recordedRun is the part of a run result needed to plan a retry.
+type recordedRun struct {
+	Nodes []json.RawMessage `json:"nodes"`
+}
+
+// FailedNode returns the node a run stopped at: the last node recorded with
+// the error status. Returns false if no node failed.
+func FailedNode(result json.RawMessage) (string, bool, error) {
+	nodes, err := decode(result)
+	if err != nil {
+		return "", false, err
+	}
+
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if nodes[i].info.Status == "error" {
+			return nodes[i].info.NodeID, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// Plan returns the recorded responses reused by a retry of the run result
+// starting at node from, keyed by node ID. Responses of from and of the nodes
+// downstream of it are left out so they are executed again.
+func Plan(wf *builder.Workflow, from string, result json.RawMessage) (map[string]json.RawMessage, error) {
+	if wf.FindNodeByID(from) == nil {
+		return nil, fmt.Errorf("node %s does not exist in the workflow", from)
+	}
+
+	nodes, err := decode(result)
+	if err != nil {
+		return nil, err
+	}
+
+	rerun := Downstream(wf, from)
+	reuse := make(map[string]json.RawMessage)
+	for _, node := range nodes {
+		if rerun[node.info.NodeID] || !ReusableStatuses[node.info.Status] {
+			continue
+		}
+		reuse[node.info.NodeID] = node.raw
+	}
+	return reuse, nil
+}
+
+// Downstream returns the set of nodes reachable from a node, the node included.
+func Downstream(wf *builder.Workflow, from string) map[string]bool {
+	reached := map[string]bool{from: true}
+	pending := []string{from}
+	for len(pending) > 0 {
+		node := wf.FindNodeByID(pending[0])
+		pending = pending[1:]
+		if node == nil {
+			continue
+		}
+		for _, next := range node.NextIDs {
+			if !reached[next] {
+				reached[next] = true
+				pending = append(pending, next)
+			}
+		}
+	}
+	return reached
+}
+
+type decodedNode struct {
+	info recordedNode
+	raw  json.RawMessage
+}
+
+// decode reads the node responses of a run result, in execution order.
+func decode(result json.RawMessage) ([]decodedNode, error) {
+	var run recordedRun
+	if err := json.Unmarshal(result, &run); err != nil {
+		return nil, fmt.Errorf("invalid run result: %w", err)
+	}
+
+	nodes := make([]decodedNode, 0, len(run.Nodes))
+	for _, raw := range run.Nodes {
+		var info recordedNode
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("invalid node response in run result: %w", err)
+		}
+		nodes = append(nodes, decodedNode{info: info, raw: raw})
+	}
+	return nodes, nil
+}
@@ -0,0 +1,205 @@
+package retry
+
+import (
+	"XKA/internal/shared/builder"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testWorkflow builds the graph
+//
+//	start -> fetch -> parse -> store
+//	             \-> notify
+//	orphan
+func testWorkflow() *builder.Workflow {
+	edges := map[string][]string{
+		"start":  {"fetch"},
+		"fetch":  {"parse", "notify"},
+		"parse":  {"store"},
+		"store":  {},
+		"notify": {},
+		"orphan": {},
+	}
+	wf := &builder.Workflow{NodeMap: map[string]*builder.Node{}, StartNodeIDs: []string{"start"}}
+	for id, next := range edges {
+		wf.NodeMap[id] = &builder.Node{ID: id, Type: "test", NextIDs: next}
+	}
+	return wf
+}
+
+func TestDownstream(t *testing.T) {
+	tests := []struct {
+		from string
+		want []string
+	}{
+		{"start", []string{"fetch", "notify", "parse", "start", "store"}},
+		{"fetch", []string{"fetch", "notify", "parse", "store"}},
+		{"parse", []string{"parse", "store"}},
+		{"store", []string{"store"}},
+		{"orphan", []string{"orphan"}},
+		{"missing", []string{"missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			if got := keys(Downstream(testWorkflow(), tt.from)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Downstream(%s) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownstreamCycle(t *testing.T) {
+	wf := testWorkflow()
+	wf.NodeMap["store"].NextIDs = []string{"fetch"}
+
+	want := []string{"fetch", "notify", "parse", "store"}
+	if got := keys(Downstream(wf, "parse")); !reflect.DeepEqual(got, want) {
+		t.Errorf("Downstream(parse) = %v, want %v", got, want)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	result := runResult(
+		`{"nodeId": "start", "status": "success"}`,
+		`{"nodeId": "fetch", "status": "reused", "result": 1}`,
+		`{"nodeId": "notify", "status": "success"}`,
+		`{"nodeId": "parse", "status": "error"}`,
+	)
+
+	tests := []struct {
+		name string
+		from string
+		want []string
+	}{
+		{"from the failed node", "parse", []string{"fetch", "notify", "start"}},
+		{"from an upstream node", "fetch", []string{"start"}},
+		{"from the trigger", "start", []string{}},
+		{"from a leaf", "notify", []string{"fetch", "start"}},
+		{"from a node never executed", "store", []string{"fetch", "notify", "start"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reuse, err := Plan(testWorkflow(), tt.from, result)
+			if err != nil {
+				t.Fatalf("Plan(%s) error = %v", tt.from, err)
+			}
+			if got := keys(reuse); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan(%s) reuses %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanReusableStatuses(t *testing.T) {
+	tests := []struct {
+		status string
+		reused bool
+	}{
+		{"success", true},
+		{"reused", true},
+		{"error", false},
+		{"skipped", false},
+		{"pinned", false},
+		{"waiting", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			result := runResult(`{"nodeId": "start", "status": "` + tt.status + `"}`)
+			reuse, err := Plan(testWorkflow(), "fetch", result)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if _, got := reuse["start"]; got != tt.reused {
+				t.Errorf("response with status %s reused = %v, want %v", tt.status, got, tt.reused)
+			}
+		})
+	}
+}
+
+func TestPlanKeepsRecordedResponse(t *testing.T) {
+	response := `{"nodeId":"start","status":"success","result":{"id":42},"logs":["started"]}`
+	reuse, err := Plan(testWorkflow(), "fetch", runResult(response))
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if got := string(reuse["start"]); got != response {
+		t.Errorf("reused response = %s, want %s", got, response)
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   string
+		result string
+	}{
+		{"unknown node", "missing", `{"nodes": []}`},
+		{"invalid result", "fetch", `{"nodes": `},
+		{"invalid node response", "fetch", `{"nodes": ["start"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Plan(testWorkflow(), tt.from, json.RawMessage(tt.result)); err == nil {
+				t.Errorf("Plan(%s, %s) succeeded, want an error", tt.from, tt.result)
+			}
+		})
+	}
+}
+
+func TestFailedNode(t *testing.T) {
+	tests := []struct {
+		name   string
+		result json.RawMessage
+		want   string
+		failed bool
+	}{
+		{"no node", runResult(), "", false},
+		{"success", runResult(`{"nodeId": "start", "status": "success"}`), "", false},
+		{"failed node", runResult(
+			`{"nodeId": "start", "status": "success"}`,
+			`{"nodeId": "fetch", "status": "error"}`,
+		), "fetch", true},
+		{"last failure wins", runResult(
+			`{"nodeId": "fetch", "status": "error"}`,
+			`{"nodeId": "notify", "status": "success"}`,
+			`{"nodeId": "parse", "status": "error"}`,
+		), "parse", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, failed, err := FailedNode(tt.result)
+			if err != nil {
+				t.Fatalf("FailedNode() error = %v", err)
+			}
+			if got != tt.want || failed != tt.failed {
+				t.Errorf("FailedNode() = %q, %v, want %q, %v", got, failed, tt.want, tt.failed)
+			}
+		})
+	}
+
+	if _, _, err := FailedNode(json.RawMessage(`[]`)); err == nil {
+		t.Error("FailedNode() of an invalid result succeeded, want an error")
+	}
+}
+
+// runResult builds a run result recording the given node responses.
+func runResult(nodes ...string) json.RawMessage {
+	raw := make([]json.RawMessage, 0, len(nodes))
+	for _, node := range nodes {
+		raw = append(raw, json.RawMessage(node))
+	}
+	data, _ := json.Marshal(map[string]interface{}{"nodes": raw})
+	return data
+}
+
+// keys returns the sorted keys of a map.
+func keys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for key := range m {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,309 @@
+package scheduler
+
+import (
+	"XKA/internal/shared/nodetypes"
+	"XKA/internal/shared/runs"
+	"XKA/internal/shared/schema"
+	"XKA/internal/worker-manager/activation"
+	"XKA/pkg/RedisClient"
+	"XKA/pkg/logger"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// CronTriggerNodeType is the node type handled by the cron scheduler.
+const CronTriggerNodeType = nodetypes.CronTriggerNode
+
+// Cron scheduler defaults.
+const (
+	DefaultCronInterval = 1 * time.Second
+	cronLockTTL         = 24 * time.Hour // Per-tick lock lifetime
+	maxCatchUpRuns      = 100            // Upper bound of runs fired per trigger and pass with CatchUpAll
+	maxTickWalk         = 1_000_000      // Upper bound of ticks walked when looking for the latest one
+)
+
+// CatchUpPolicy decides what happens to ticks missed while no manager was running.
+type CatchUpPolicy string
+
+const (
+	CatchUpSkip   CatchUpPolicy = "skip"   // Drop missed ticks, only fire ticks that are on time
+	CatchUpLatest CatchUpPolicy = "latest" // Fire a single run for the most recent missed tick
+	CatchUpAll    CatchUpPolicy = "all"    // Fire one run per missed tick (bounded per pass)
+)
+
+// cronParser accepts standard 5-field expressions, an optional leading
+// seconds field (6 fields) and descriptors such as "@hourly".
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ParseCatchUpPolicy validates a catch-up policy name.
+func ParseCatchUpPolicy(name string) (CatchUpPolicy, error) {
+	switch policy := CatchUpPolicy(strings.ToLower(strings.TrimSpace(name))); policy {
+	case CatchUpSkip, CatchUpLatest, CatchUpAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid catch-up policy %q: expected skip, latest or all", name)
+	}
+}
+
+// CronSpec is the validated configuration of a cronTriggerNode.
+type CronSpec struct {
+	Expression string        // Cron expression as configured
+	Timezone   string        // IANA timezone name
+	CatchUp    CatchUpPolicy // Policy for ticks missed during downtime
+
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// ParseCronSpec reads the configuration of a cronTriggerNode (see nodetypes.CronTriggerConfig).
+// Expected data: {"expression": "*/5 * * * *", "timezone": "Europe/Paris", "catchUp": "latest"}.
+// Timezone defaults to UTC and catchUp to the given default policy.
+func ParseCronSpec(data map[string]interface{}, defaultPolicy CatchUpPolicy) (*CronSpec, error) {
+	var config nodetypes.CronTriggerConfig
+	if errs := schema.Decode(data, &config); len(errs) > 0 {
+		return nil, errs
+	}
+
+	expression := strings.TrimSpace(config.Expression)
+	if expression == "" {
+		return nil, fmt.Errorf("missing 'expression' parameter")
+	}
+
+	timezone := strings.TrimSpace(config.Timezone)
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %v", timezone, err)
+	}
+
+	schedule, err := cronParser.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", expression, err)
+	}
+
+	policy := defaultPolicy
+	if config.CatchUp != "" {
+		policy = CatchUpPolicy(config.CatchUp)
+	}
+
+	return &CronSpec{
+		Expression: expression,
+		Timezone:   timezone,
+		CatchUp:    policy,
+		schedule:   schedule,
+		location:   location,
+	}, nil
+}
+
+// Next returns the first tick strictly after t, in the spec timezone.
+func (cs *CronSpec) Next(t time.Time) time.Time {
+	return cs.schedule.Next(t.In(cs.location))
+}
+
+// DueTicks returns the ticks in (last, now] that must fire according to the
+// catch-up policy, along with the most recent tick reached (zero if none).
+// grace is how late a tick may be and still count as on time for CatchUpSkip.
+func (cs *CronSpec) DueTicks(last, now time.Time, grace time.Duration) ([]time.Time, time.Time) {
+	if cs.CatchUp == CatchUpAll {
+		ticks := make([]time.Time, 0, 1)
+		for t := cs.Next(last); !t.IsZero() && !t.After(now) && len(ticks) < maxCatchUpRuns; t = cs.Next(t) {
+			ticks = append(ticks, t)
+		}
+		if len(ticks) == 0 {
+			return nil, time.Time{}
+		}
+		return ticks, ticks[len(ticks)-1]
+	}
+
+	// Only the most recent tick matters: walk forward without keeping history
+	var latest time.Time
+	for t, walked := cs.Next(last), 0; !t.IsZero() && !t.After(now) && walked < maxTickWalk; t, walked = cs.Next(t), walked+1 {
+		latest = t
+	}
+	if latest.IsZero() {
+		return nil, time.Time{}
+	}
+
+	if cs.CatchUp == CatchUpSkip && now.Sub(latest) > grace {
+		return nil, latest
+	}
+	return []time.Time{latest}, latest
+}
+
+// CronScheduler enqueues a run for every tick of the cronTriggerNodes of
+// activated workflows. Replicas coordinate through a Redis lock per tick so
+// each tick fires exactly once whatever the number of managers.
+type CronScheduler struct {
+	client        *RedisClient.Client
+	logger        *zap.Logger
+	interval      time.Duration
+	defaultPolicy CatchUpPolicy
+}
+
+// NewCronScheduler creates a cron scheduler evaluating triggers at the given interval.
+// The default catch-up policy is read from CRON_CATCHUP_POLICY (defaults to skip).
+func NewCronScheduler(client *RedisClient.Client, interval time.Duration) *CronScheduler {
+	if interval <= 0 {
+		interval = DefaultCronInterval
+	}
+
+	policy := CatchUpSkip
+	if raw := os.Getenv("CRON_CATCHUP_POLICY"); raw != "" {
+		parsed, err := ParseCatchUpPolicy(raw)
+		if err != nil {
+			logger.Log.Warn("Ignoring invalid CRON_CATCHUP_POLICY", zap.Error(err))
+		} else {
+			policy = parsed
+		}
+	}
+
+	return &CronScheduler{
+		client:        client,
+		logger:        logger.Log,
+		interval:      interval,
+		defaultPolicy: policy,
+	}
+}
+
+// DefaultPolicy returns the catch-up policy applied when a trigger does not set one.
+func (cs *CronScheduler) DefaultPolicy() CatchUpPolicy {
+	return cs.defaultPolicy
+}
+
+// Run evaluates cron triggers until the context is cancelled.
+func (cs *CronScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(cs.interval)
+	defer ticker.Stop()
+
+	cs.logger.Info("Cron scheduler started",
+		zap.Duration("interval", cs.interval),
+		zap.String("default_catch_up", string(cs.defaultPolicy)),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			cs.logger.Info("Cron scheduler stopped")
+			return
+		case <-ticker.C:
+			if err := cs.evaluate(time.Now()); err != nil {
+				cs.logger.Error("Failed to evaluate cron triggers", zap.Error(err))
+			}
+		}
+	}
+}
+
+// evaluate fires the due ticks of every active cron trigger.
+func (cs *CronScheduler) evaluate(now time.Time) error {
+	activations, err := activation.List(cs.client)
+	if err != nil {
+		return err
+	}
+
+	for _, act := range activations {
+		for _, trigger := range act.TriggersOfType(CronTriggerNodeType) {
+			if err := cs.evaluateTrigger(act, trigger, now); err != nil {
+				cs.logger.Error("Failed to evaluate cron trigger",
+					zap.String("workflow_id", act.WorkflowID),
+					zap.String("node_id", trigger.NodeID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateTrigger fires the due ticks of a single trigger.
+func (cs *CronScheduler) evaluateTrigger(act *activation.Activation, trigger activation.Trigger, now time.Time) error {
+	spec, err := ParseCronSpec(trigger.Data, cs.defaultPolicy)
+	if err != nil {
+		return err
+	}
+
+	lastKey := cronLastKey(act.WorkflowID, trigger.NodeID)
+	last := time.UnixMilli(act.ActivatedAt)
+	if raw, err := cs.client.Get(lastKey); err == nil {
+		if ms, convErr := strconv.ParseInt(raw, 10, 64); convErr == nil && ms > act.ActivatedAt {
+			last = time.UnixMilli(ms)
+		}
+	}
+
+	ticks, reached := spec.DueTicks(last, now, 2*cs.interval)
+	for _, tick := range ticks {
+		if err := cs.fire(act, trigger, spec, tick, now); err != nil {
+			return err
+		}
+	}
+
+	if !reached.IsZero() {
+		return cs.client.Set(lastKey, strconv.FormatInt(reached.UnixMilli(), 10), 0)
+	}
+	return nil
+}
+
+// fire enqueues the run of a tick if this replica wins the tick lock.
+func (cs *CronScheduler) fire(act *activation.Activation, trigger activation.Trigger, spec *CronSpec, tick, now time.Time) error {
+	lockKey := fmt.Sprintf("cron:lock:%s:%s:%d", act.WorkflowID, trigger.NodeID, tick.UnixMilli())
+	acquired, err := cs.client.SetNX(lockKey, now.UnixMilli(), cronLockTTL)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return nil // Another replica already fired this tick
+	}
+
+	job := act.NewJob(trigger, map[string]interface{}{
+		"firedAt":     tick.In(spec.location).Format(time.RFC3339),
+		"triggeredAt": now.UTC().Format(time.RFC3339),
+		"expression":  spec.Expression,
+		"timezone":    spec.Timezone,
+	})
+
+	if err := runs.Enqueue(cs.client, job); err != nil {
+		// Release the tick so the next pass fires it again
+		if delErr := cs.client.Delete(lockKey); delErr != nil {
+			cs.logger.Warn("Failed to release cron tick lock", zap.String("key", lockKey), zap.Error(delErr))
+		}
+		return err
+	}
+
+	cs.logger.Info("Cron trigger fired",
+		zap.String("workflow_id", act.WorkflowID),
+		zap.String("node_id", trigger.NodeID),
+		zap.String("run_id", job.RunID),
+		zap.Time("tick", tick),
+	)
+	return nil
+}
+
+// ResetCronState forgets the last fired ticks of an activation's cron triggers,
+// so a later activation does not catch up on ticks from a previous one.
+func ResetCronState(client *RedisClient.Client, act *activation.Activation) error {
+	triggers := act.TriggersOfType(CronTriggerNodeType)
+	if len(triggers) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(triggers))
+	for _, trigger := range triggers {
+		keys = append(keys, cronLastKey(act.WorkflowID, trigger.NodeID))
+	}
+	return client.Delete(keys...)
+}
+
+func cronLastKey(workflowID, nodeID string) string {
+	return fmt.Sprintf("cron:last:%s:%s", workflowID, nodeID)
+}
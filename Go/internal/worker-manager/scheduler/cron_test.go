@@ -0,0 +1,197 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCatchUpPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    CatchUpPolicy
+		wantErr bool
+	}{
+		{"skip", CatchUpSkip, false},
+		{"latest", CatchUpLatest, false},
+		{"all", CatchUpAll, false},
+		{" Latest ", CatchUpLatest, false},
+		{"", "", true},
+		{"every", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCatchUpPolicy(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseCatchUpPolicy(%q) = %q, %v, want %q (error: %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseCronSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]interface{}
+		timezone string
+		policy   CatchUpPolicy
+		wantErr  bool
+	}{
+		{"five fields", map[string]interface{}{"expression": "*/5 * * * *"}, "UTC", CatchUpSkip, false},
+		{"seconds field", map[string]interface{}{"expression": "30 */5 * * * *"}, "UTC", CatchUpSkip, false},
+		{"descriptor", map[string]interface{}{"expression": "@hourly"}, "UTC", CatchUpSkip, false},
+		{"timezone", map[string]interface{}{"expression": "0 9 * * *", "timezone": "Europe/Paris"}, "Europe/Paris", CatchUpSkip, false},
+		{"blank timezone", map[string]interface{}{"expression": "0 9 * * *", "timezone": " "}, "UTC", CatchUpSkip, false},
+		{"catch-up policy", map[string]interface{}{"expression": "0 9 * * *", "catchUp": "all"}, "UTC", CatchUpAll, false},
+		{"missing expression", map[string]interface{}{}, "", "", true},
+		{"blank expression", map[string]interface{}{"expression": "  "}, "", "", true},
+		{"invalid expression", map[string]interface{}{"expression": "every minute"}, "", "", true},
+		{"out of range field", map[string]interface{}{"expression": "0 25 * * *"}, "", "", true},
+		{"invalid timezone", map[string]interface{}{"expression": "0 9 * * *", "timezone": "Mars/Olympus"}, "", "", true},
+		{"invalid catch-up policy", map[string]interface{}{"expression": "0 9 * * *", "catchUp": "some"}, "", "", true},
+		{"blank catch-up policy", map[string]interface{}{"expression": "0 9 * * *", "catchUp": ""}, "", "", true},
+		{"catch-up policy is case-sensitive", map[string]interface{}{"expression": "0 9 * * *", "catchUp": "Latest"}, "", "", true},
+		{"expression of the wrong type", map[string]interface{}{"expression": 5}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseCronSpec(tt.data, CatchUpSkip)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCronSpec(%v) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if spec.Timezone != tt.timezone || spec.CatchUp != tt.policy {
+				t.Errorf("ParseCronSpec(%v) = %s, %s, want %s, %s", tt.data, spec.Timezone, spec.CatchUp, tt.timezone, tt.policy)
+			}
+		})
+	}
+}
+
+func TestCronSpecNext(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every five minutes",
+			data: map[string]interface{}{"expression": "*/5 * * * *"},
+			from: time.Date(2026, 3, 1, 10, 2, 0, 0, time.UTC),
+			want: time.Date(2026, 3, 1, 10, 5, 0, 0, time.UTC),
+		},
+		{
+			name: "strictly after",
+			data: map[string]interface{}{"expression": "*/5 * * * *"},
+			from: time.Date(2026, 3, 1, 10, 5, 0, 0, time.UTC),
+			want: time.Date(2026, 3, 1, 10, 10, 0, 0, time.UTC),
+		},
+		{
+			name: "seconds field",
+			data: map[string]interface{}{"expression": "30 * * * * *"},
+			from: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 3, 1, 10, 0, 30, 0, time.UTC),
+		},
+		{
+			name: "in the spec timezone",
+			data: map[string]interface{}{"expression": "0 9 * * *", "timezone": "Europe/Paris"},
+			from: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 15, 9, 0, 0, 0, paris), // 08:00 UTC in winter
+		},
+		{
+			name: "across a daylight saving change",
+			data: map[string]interface{}{"expression": "0 9 * * *", "timezone": "Europe/Paris"},
+			from: time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 3, 29, 9, 0, 0, 0, paris), // 07:00 UTC in summer
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseCronSpec(tt.data, CatchUpSkip)
+			if err != nil {
+				t.Fatalf("ParseCronSpec() error = %v", err)
+			}
+			if got := spec.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDueTicks(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	grace := 30 * time.Second
+
+	tests := []struct {
+		name   string
+		policy CatchUpPolicy
+		last   time.Time
+		now    time.Time
+		want   []time.Time
+		latest time.Time
+	}{
+		// Every five minutes: ticks at 10:05, 10:10, 10:15...
+		{"skip, nothing due", CatchUpSkip, at(0), at(4), nil, time.Time{}},
+		{"skip, tick on time", CatchUpSkip, at(0), at(5).Add(10 * time.Second), []time.Time{at(5)}, at(5)},
+		{"skip, tick exactly now", CatchUpSkip, at(0), at(5), []time.Time{at(5)}, at(5)},
+		{"skip, tick too late", CatchUpSkip, at(0), at(6), nil, at(5)},
+		{"skip, missed ticks dropped", CatchUpSkip, at(0), at(16), nil, at(15)},
+		{"skip, latest on time after downtime", CatchUpSkip, at(0), at(15).Add(grace), []time.Time{at(15)}, at(15)},
+		{"latest, nothing due", CatchUpLatest, at(0), at(4), nil, time.Time{}},
+		{"latest, late tick fired", CatchUpLatest, at(0), at(6), []time.Time{at(5)}, at(5)},
+		{"latest, one run for missed ticks", CatchUpLatest, at(0), at(21), []time.Time{at(20)}, at(20)},
+		{"all, nothing due", CatchUpAll, at(0), at(4), nil, time.Time{}},
+		{"all, one run per missed tick", CatchUpAll, at(0), at(21), []time.Time{at(5), at(10), at(15), at(20)}, at(20)},
+		{"all, last tick excluded", CatchUpAll, at(5), at(10), []time.Time{at(10)}, at(10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseCronSpec(map[string]interface{}{"expression": "*/5 * * * *", "catchUp": string(tt.policy)}, CatchUpSkip)
+			if err != nil {
+				t.Fatalf("ParseCronSpec() error = %v", err)
+			}
+			got, latest := spec.DueTicks(tt.last, tt.now, grace)
+			if !equalTimes(got, tt.want) {
+				t.Errorf("DueTicks() ticks = %v, want %v", got, tt.want)
+			}
+			if !latest.Equal(tt.latest) {
+				t.Errorf("DueTicks() latest = %v, want %v", latest, tt.latest)
+			}
+		})
+	}
+}
+
+func TestDueTicksCatchUpAllIsBounded(t *testing.T) {
+	spec, err := ParseCronSpec(map[string]interface{}{"expression": "* * * * *", "catchUp": "all"}, CatchUpSkip)
+	if err != nil {
+		t.Fatalf("ParseCronSpec() error = %v", err)
+	}
+
+	last := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	ticks, latest := spec.DueTicks(last, last.Add(24*time.Hour), time.Second)
+	if len(ticks) != maxCatchUpRuns {
+		t.Fatalf("DueTicks() returned %d ticks, want %d", len(ticks), maxCatchUpRuns)
+	}
+	// The next pass resumes from the latest tick fired
+	if want := last.Add(maxCatchUpRuns * time.Minute); !latest.Equal(want) {
+		t.Errorf("DueTicks() latest = %v, want %v", latest, want)
+	}
+}
+
+// equalTimes compares instants, whatever their location.
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
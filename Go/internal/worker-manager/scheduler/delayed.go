@@ -0,0 +1,322 @@
+// Package scheduler holds the time-based job scheduling of the worker manager.
+package scheduler
+
+import (
+	"XKA/internal/shared/queue"
+	"XKA/internal/shared/runs"
+	"XKA/pkg/RedisClient"
+	"XKA/pkg/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Redis keys used by the delayed job scheduler.
+const (
+	ScheduledSetKey  = "workflows:scheduled"      // Sorted set of run IDs scored by due time (unix ms)
+	ScheduledJobsKey = "workflows:scheduled:jobs" // Hash of run ID -> ScheduledJob JSON
+)
+
+// Promoter defaults.
+const (
+	DefaultPromoteInterval = 1 * time.Second
+	promoteBatchSize       = 100
+)
+
+// ScheduledJob is a job waiting in the sorted set for its due time.
+type ScheduledJob struct {
+	ID         string     `json:"id"`         // Run ID of the wrapped job
+	WorkflowID string     `json:"workflowId"` // Workflow the job executes
+	RunAt      int64      `json:"runAt"`      // Due time (unix milliseconds)
+	CreatedAt  int64      `json:"createdAt"`  // Submission time (unix milliseconds)
+	Job        *queue.Job `json:"job"`        // Job pushed on the execution queue when due
+}
+
+// ParseRunAt extracts the requested execution time from a submission payload.
+// "runAt" accepts an RFC3339 timestamp or unix seconds, "delay" accepts a Go
+// duration string ("90s", "5m") or a number of milliseconds.
+// The boolean is false when neither field is present.
+func ParseRunAt(payload map[string]interface{}, now time.Time) (time.Time, bool, error) {
+	rawRunAt, hasRunAt := payload["runAt"]
+	rawDelay, hasDelay := payload["delay"]
+
+	if hasRunAt && rawRunAt == nil {
+		hasRunAt = false
+	}
+	if hasDelay && rawDelay == nil {
+		hasDelay = false
+	}
+
+	switch {
+	case hasRunAt && hasDelay:
+		return time.Time{}, false, fmt.Errorf("runAt and delay are mutually exclusive")
+	case hasRunAt:
+		runAt, err := parseTimestamp(rawRunAt)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid runAt: %w", err)
+		}
+		return runAt, true, nil
+	case hasDelay:
+		delay, err := parseDelay(rawDelay)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid delay: %w", err)
+		}
+		return now.Add(delay), true, nil
+	default:
+		return time.Time{}, false, nil
+	}
+}
+
+// parseTimestamp accepts an RFC3339 string or unix seconds.
+func parseTimestamp(raw interface{}) (time.Time, error) {
+	switch v := raw.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(v)); err == nil {
+			return t, nil
+		}
+		secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("expected RFC3339 timestamp or unix seconds")
+		}
+		return time.Unix(secs, 0), nil
+	case float64:
+		return time.Unix(int64(v), 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("expected RFC3339 timestamp or unix seconds")
+	}
+}
+
+// parseDelay accepts a Go duration string or a number of milliseconds.
+func parseDelay(raw interface{}) (time.Duration, error) {
+	var delay time.Duration
+	switch v := raw.(type) {
+	case string:
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			ms, convErr := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if convErr != nil {
+				return 0, fmt.Errorf("expected duration (e.g. \"90s\") or milliseconds")
+			}
+			d = time.Duration(ms) * time.Millisecond
+		}
+		delay = d
+	case float64:
+		delay = time.Duration(v) * time.Millisecond
+	default:
+		return 0, fmt.Errorf("expected duration (e.g. \"90s\") or milliseconds")
+	}
+
+	if delay < 0 {
+		return 0, fmt.Errorf("delay cannot be negative")
+	}
+	return delay, nil
+}
+
+// Schedule stores a job and registers it for promotion at runAt.
+// The caller records the run with the scheduled status beforehand.
+func Schedule(client *RedisClient.Client, job *queue.Job, runAt time.Time) (*ScheduledJob, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client not initialized")
+	}
+	if job == nil {
+		return nil, fmt.Errorf("job cannot be nil")
+	}
+
+	scheduled := &ScheduledJob{
+		ID:         job.RunID,
+		WorkflowID: job.WorkflowID,
+		RunAt:      runAt.UnixMilli(),
+		CreatedAt:  time.Now().UnixMilli(),
+		Job:        job,
+	}
+
+	data, err := json.Marshal(scheduled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal scheduled job: %w", err)
+	}
+
+	// Store the payload first so the promoter never sees an ID without data
+	if err := client.HSet(ScheduledJobsKey, scheduled.ID, string(data)); err != nil {
+		return nil, err
+	}
+	if err := client.ZAdd(ScheduledSetKey, float64(scheduled.RunAt), scheduled.ID); err != nil {
+		client.HDel(ScheduledJobsKey, scheduled.ID)
+		return nil, err
+	}
+
+	return scheduled, nil
+}
+
+// Get returns a scheduled job that has not fired yet.
+func Get(client *RedisClient.Client, id string) (*ScheduledJob, error) {
+	raw, err := client.HGet(ScheduledJobsKey, id)
+	if err != nil {
+		return nil, err
+	}
+	return decodeScheduledJob(raw)
+}
+
+// List returns every pending scheduled job ordered by due time.
+func List(client *RedisClient.Client) ([]*ScheduledJob, error) {
+	entries, err := client.HGetAll(ScheduledJobsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*ScheduledJob, 0, len(entries))
+	for id, raw := range entries {
+		job, err := decodeScheduledJob(raw)
+		if err != nil {
+			logger.Log.Warn("Skipping corrupted scheduled job", zap.String("id", id), zap.Error(err))
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].RunAt < jobs[j].RunAt })
+	return jobs, nil
+}
+
+// Cancel removes a scheduled job before it fires.
+// Returns false if the job does not exist or has already been promoted.
+func Cancel(client *RedisClient.Client, id string) (bool, error) {
+	removed, err := client.ZRem(ScheduledSetKey, id)
+	if err != nil {
+		return false, err
+	}
+	if removed == 0 {
+		return false, nil
+	}
+	if _, err := client.HDel(ScheduledJobsKey, id); err != nil {
+		return true, err
+	}
+	if err := runs.Cancel(client, id); err != nil {
+		logger.Log.Warn("Failed to mark scheduled run as cancelled", zap.String("run_id", id), zap.Error(err))
+	}
+	return true, nil
+}
+
+func decodeScheduledJob(raw string) (*ScheduledJob, error) {
+	var job ScheduledJob
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, fmt.Errorf("failed to decode scheduled job: %w", err)
+	}
+	if job.Job == nil {
+		return nil, fmt.Errorf("scheduled job %s has no job payload", job.ID)
+	}
+	return &job, nil
+}
+
+// Promoter moves due scheduled jobs onto the execution queues.
+// Several manager replicas may run a promoter concurrently: a job is only
+// promoted by the replica whose ZREM actually removed it from the set.
+type Promoter struct {
+	client   *RedisClient.Client
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+// NewPromoter creates a promoter polling the scheduled set at the given interval.
+func NewPromoter(client *RedisClient.Client, interval time.Duration) *Promoter {
+	if interval <= 0 {
+		interval = DefaultPromoteInterval
+	}
+	return &Promoter{
+		client:   client,
+		logger:   logger.Log,
+		interval: interval,
+	}
+}
+
+// Run promotes due jobs until the context is cancelled.
+func (p *Promoter) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.logger.Info("Scheduled job promoter started", zap.Duration("interval", p.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Scheduled job promoter stopped")
+			return
+		case <-ticker.C:
+			if err := p.promoteDue(time.Now()); err != nil {
+				p.logger.Error("Failed to promote scheduled jobs", zap.Error(err))
+			}
+		}
+	}
+}
+
+// promoteDue enqueues every job whose due time is not after now.
+func (p *Promoter) promoteDue(now time.Time) error {
+	due, err := p.client.ZRangeByScore(ScheduledSetKey, "-inf", strconv.FormatInt(now.UnixMilli(), 10), promoteBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range due {
+		// Claim the job: only one replica gets removed == 1
+		removed, err := p.client.ZRem(ScheduledSetKey, id)
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			continue
+		}
+
+		if err := p.promote(id, now); err != nil {
+			p.logger.Error("Failed to promote scheduled job",
+				zap.String("run_id", id),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+// promote pushes a claimed job on its execution queue and drops its payload.
+// When the job cannot be read or pushed, the claim is released by adding it back
+// to the sorted set, so the next pass tries again and the job stays listed and
+// cancellable. Only an undecodable payload is dropped.
+func (p *Promoter) promote(id string, now time.Time) error {
+	raw, err := p.client.HGet(ScheduledJobsKey, id)
+	if err != nil {
+		p.release(id, float64(now.UnixMilli()))
+		return err
+	}
+	scheduled, err := decodeScheduledJob(raw)
+	if err != nil {
+		p.client.HDel(ScheduledJobsKey, id)
+		return err
+	}
+
+	if err := runs.Enqueue(p.client, scheduled.Job); err != nil {
+		p.release(id, float64(scheduled.RunAt))
+		return err
+	}
+
+	if _, err := p.client.HDel(ScheduledJobsKey, id); err != nil {
+		return err
+	}
+
+	p.logger.Info("Scheduled job promoted",
+		zap.String("run_id", id),
+		zap.String("workflow_id", scheduled.WorkflowID),
+		zap.Int64("late_ms", time.Now().UnixMilli()-scheduled.RunAt),
+	)
+	return nil
+}
+
+// release puts a claimed job back in the sorted set after a failed promotion.
+func (p *Promoter) release(id string, score float64) {
+	if err := p.client.ZAdd(ScheduledSetKey, score, id); err != nil {
+		p.logger.Error("Failed to release scheduled job", zap.String("run_id", id), zap.Error(err))
+	}
+}
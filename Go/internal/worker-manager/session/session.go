@@ -0,0 +1,290 @@
+// Package session defines the WebSocket protocol of interactive editor sessions.
+//
+// An editor opens one connection on GET /api/v1/session and uses it to submit
+// runs, follow their events live and control them, instead of combining
+// several REST calls.
+//
+// # Envelope
+//
+// Every message, in both directions, is a JSON object:
+//
+//	{
+//	  "v":       1,             // Protocol version (required)
+//	  "type":    "run.submit",  // Message type (required)
+//	  "id":      "c-42",        // Client correlation ID, echoed in the reply (optional)
+//	  "runId":   "run_...",     // Run concerned, when relevant
+//	  "payload": { ... }        // Type-specific content
+//	}
+//
+// Messages with an unsupported version are rejected with an error message.
+// Fields unknown to a version are ignored, so a version only changes when
+// an existing field changes meaning.
+//
+// # Client messages
+//
+//   - run.submit: starts a run. The payload is either a workflow submission
+//     (same body as POST /api/v1/workflow) or {"workflowId": "...", "revision": n}
+//     for a stored workflow, plus the usual run options (priority, mode, runAt,
+//     delay, triggerNodeId, input). The run is followed automatically unless "subscribe" is false.
+//     Replied with ack, payload: the run description returned by the REST API.
+//   - run.subscribe: follows an existing run (runId required). The optional
+//     payload {"lastEventId": "..."} resumes after an event already received.
+//     Replied with ack.
+//   - run.unsubscribe: stops following a run. Replied with ack.
+//   - run.control: sends a command to a run (runId required), payload
+//     {"action": "cancel" | "pause" | "resume" | "step"}, or the debugger
+//     aliases "continue" (resume) and "abort" (cancel). Commands take effect
+//     between nodes. Replied with ack, payload: the run record.
+//   - ping: replied with pong.
+//
+// # Server messages
+//
+//   - welcome: sent once on connection, payload {"version": 1, "versions": [1]}.
+//   - ack: success reply to a client message, carrying its id.
+//   - error: failure reply, carrying the id of the faulty message if any,
+//     payload {"code": "...", "message": "...", "detail": "..."}. A rejected run
+//     input adds "errors": [{"path": "/input/...", "code": "...", "message": "..."}].
+//   - run.event: an event of a followed run (see package runlog), payload
+//     {"id", "type", "runId", "nodeId", "nodeType", "position", "timestamp", "data"}.
+//     Event types: run.started, node.started, node.log, node.finished,
+//     run.paused, run.resumed, run.waiting, run.finished. The data of run.paused
+//     describes the pending node: {"reason": "breakpoint" | "paused", "nodeId",
+//     "nodeType", "position", "inputs": {"data", "input", "previous"}}.
+//     The data of run.waiting is the response of the node the run is suspended
+//     at, with status "waiting": {"nodeId", "nodeType", "status", "result":
+//     {"correlationKey", "timeoutAt"}, "logs", ...}. The run stays followed: a
+//     run.resumed event follows once the awaited event arrives or times out.
+//   - run.end: the followed run is over, payload {"runId", "status"}.
+//     No more events are sent for the run.
+//   - pong: reply to ping.
+package session
+
+import (
+	"XKA/internal/shared/schema"
+	"XKA/internal/worker-manager/parser"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Version is the protocol version spoken by the server.
+const Version = 1
+
+// SupportedVersions lists the protocol versions accepted from clients.
+var SupportedVersions = []int{Version}
+
+// Connection limits.
+const (
+	WriteTimeout   = 10 * time.Second
+	PongTimeout    = 60 * time.Second
+	PingInterval   = (PongTimeout * 9) / 10
+	MaxMessageSize = 1 << 20 // 1MB
+)
+
+// Type identifies a message.
+type Type string
+
+// Client messages.
+const (
+	TypeSubmit      Type = "run.submit"
+	TypeSubscribe   Type = "run.subscribe"
+	TypeUnsubscribe Type = "run.unsubscribe"
+	TypeControl     Type = "run.control"
+	TypePing        Type = "ping"
+)
+
+// Server messages.
+const (
+	TypeWelcome Type = "welcome"
+	TypeAck     Type = "ack"
+	TypeError   Type = "error"
+	TypeEvent   Type = "run.event"
+	TypeEnd     Type = "run.end"
+	TypePong    Type = "pong"
+)
+
+// Error codes.
+const (
+	CodeInvalidMessage     = "invalid_message"
+	CodeUnsupportedVersion = "unsupported_version"
+	CodeUnknownType        = "unknown_type"
+	CodeInvalidRequest     = "invalid_request"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeInternal           = "internal_error"
+)
+
+// Message is the envelope of every message.
+type Message struct {
+	V       int             `json:"v"`
+	Type    Type            `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	RunID   string          `json:"runId,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ErrorPayload describes a failure.
+type ErrorPayload struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Detail  string         `json:"detail,omitempty"`
+	Errors  schema.Errors  `json:"errors,omitempty"` // Field-level problems, e.g. of a run input
+	Issues  []parser.Issue `json:"issues,omitempty"` // Structural problems of a submitted workflow graph
+}
+
+// Error implements the error interface.
+func (e *ErrorPayload) Error() string {
+	if e.Detail == "" {
+		return e.Message
+	}
+	return e.Message + ": " + e.Detail
+}
+
+// WelcomePayload is sent when a session opens.
+type WelcomePayload struct {
+	Version  int   `json:"version"`
+	Versions []int `json:"versions"`
+}
+
+// SubscribePayload is the payload of run.subscribe.
+type SubscribePayload struct {
+	LastEventID string `json:"lastEventId,omitempty"`
+}
+
+// ControlPayload is the payload of run.control.
+type ControlPayload struct {
+	Action string `json:"action"`
+}
+
+// EndPayload is the payload of run.end.
+type EndPayload struct {
+	RunID  string `json:"runId"`
+	Status string `json:"status"`
+}
+
+// Decode reads a client message and checks its envelope.
+func Decode(data []byte) (*Message, *ErrorPayload) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, &ErrorPayload{Code: CodeInvalidMessage, Message: "Invalid JSON message", Detail: err.Error()}
+	}
+
+	supported := false
+	for _, v := range SupportedVersions {
+		if msg.V == v {
+			supported = true
+		}
+	}
+	if !supported {
+		return &msg, &ErrorPayload{
+			Code:    CodeUnsupportedVersion,
+			Message: "Unsupported protocol version",
+			Detail:  fmt.Sprintf("version %d is not supported (expected one of %v)", msg.V, SupportedVersions),
+		}
+	}
+
+	switch msg.Type {
+	case TypeSubmit, TypeSubscribe, TypeUnsubscribe, TypeControl, TypePing:
+	case "":
+		return &msg, &ErrorPayload{Code: CodeInvalidMessage, Message: "Missing message type"}
+	default:
+		return &msg, &ErrorPayload{Code: CodeUnknownType, Message: "Unknown message type", Detail: string(msg.Type)}
+	}
+
+	if (msg.Type == TypeSubscribe || msg.Type == TypeUnsubscribe || msg.Type == TypeControl) && msg.RunID == "" {
+		return &msg, &ErrorPayload{Code: CodeInvalidRequest, Message: "Missing runId", Detail: string(msg.Type) + " requires a runId"}
+	}
+	return &msg, nil
+}
+
+// NewUpgrader returns the upgrader of session connections.
+// Browsers are only accepted from the server's own origin and from the
+// origins listed in SESSION_ALLOWED_ORIGINS (comma-separated, "*" for any).
+func NewUpgrader() *websocket.Upgrader {
+	allowed := make(map[string]bool)
+	for _, origin := range strings.Split(os.Getenv("SESSION_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			allowed[strings.ToLower(origin)] = true
+		}
+	}
+
+	return &websocket.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" || allowed["*"] || allowed[strings.ToLower(origin)] {
+				return true
+			}
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		},
+	}
+}
+
+// Conn is a session connection. Send is safe for concurrent use.
+type Conn struct {
+	ws *websocket.Conn
+	mu sync.Mutex
+}
+
+// NewConn wraps an upgraded WebSocket connection and sets its limits.
+func NewConn(ws *websocket.Conn) *Conn {
+	ws.SetReadLimit(MaxMessageSize)
+	ws.SetReadDeadline(time.Now().Add(PongTimeout))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(PongTimeout))
+	})
+	return &Conn{ws: ws}
+}
+
+// Read waits for the next client message.
+func (c *Conn) Read() ([]byte, error) {
+	_, data, err := c.ws.ReadMessage()
+	if err == nil {
+		// Any message proves the client is alive
+		c.ws.SetReadDeadline(time.Now().Add(PongTimeout))
+	}
+	return data, err
+}
+
+// Send writes a server message.
+func (c *Conn) Send(msgType Type, id, runID string, payload interface{}) error {
+	msg := Message{V: Version, Type: msgType, ID: id, RunID: runID}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s payload: %w", msgType, err)
+		}
+		msg.Payload = data
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ws.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	return c.ws.WriteJSON(msg)
+}
+
+// SendError writes an error reply.
+func (c *Conn) SendError(id, runID string, payload *ErrorPayload) error {
+	return c.Send(TypeError, id, runID, payload)
+}
+
+// Ping sends a keep-alive ping; the client's pong extends the read deadline.
+func (c *Conn) Ping() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(WriteTimeout))
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.ws.Close()
+}
@@ -0,0 +1,296 @@
+// Package webhook exposes webhookTriggerNodes of activated workflows as HTTP endpoints.
+package webhook
+
+import (
+	"XKA/internal/shared/nodetypes"
+	"XKA/internal/shared/schema"
+	"XKA/internal/worker-manager/activation"
+	"XKA/pkg/RedisClient"
+	"XKA/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TriggerNodeType is the node type exposed as a webhook.
+const TriggerNodeType = nodetypes.WebhookTriggerNode
+
+// RoutesKey is the Redis hash of webhook path -> Route JSON.
+const RoutesKey = "webhooks:routes"
+
+// Webhook defaults and limits.
+const (
+	DefaultTimeout = 10 * time.Second
+	MaxTimeout     = 25 * time.Second // Kept under the router timeout (maximum of the timeout schema)
+	MaxBodySize    = 1 << 20          // 1MB
+)
+
+// Mode selects how the webhook answers the caller.
+type Mode string
+
+const (
+	ModeAsync Mode = "async" // Answer 202 as soon as the run is queued
+	ModeSync  Mode = "sync"  // Wait for the run and answer with the last node output
+)
+
+// Spec is the validated configuration of a webhookTriggerNode.
+type Spec struct {
+	Path    string        // Path under /hooks/
+	Methods []string      // Allowed HTTP methods
+	Mode    Mode          // Response mode
+	Timeout time.Duration // Maximum wait in sync mode
+}
+
+// Route maps a webhook path to the trigger that owns it.
+type Route struct {
+	WorkflowID string `json:"workflowId"`
+	NodeID     string `json:"nodeId"`
+}
+
+// NormalizePath trims surrounding slashes and spaces from a webhook path.
+func NormalizePath(path string) string {
+	return strings.Trim(strings.TrimSpace(path), "/")
+}
+
+// ParseSpec reads the configuration of a webhookTriggerNode (see nodetypes.WebhookTriggerConfig).
+// Expected data: {"path": "orders/new", "methods": ["POST"], "mode": "sync", "timeout": "10s"}.
+// Methods defaults to POST, mode to async and timeout to DefaultTimeout.
+func ParseSpec(data map[string]interface{}) (*Spec, error) {
+	var config nodetypes.WebhookTriggerConfig
+	if errs := schema.Decode(data, &config); len(errs) > 0 {
+		return nil, errs
+	}
+
+	methods, err := parseMethods(config.Methods)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Spec{
+		Path:    NormalizePath(config.Path),
+		Methods: methods,
+		Mode:    Mode(config.Mode),
+		Timeout: timeout,
+	}, nil
+}
+
+// parseMethods checks the HTTP methods of a webhook.
+// Each item may list several comma separated methods, in any case.
+func parseMethods(names []string) ([]string, error) {
+	methods := make([]string, 0, len(names))
+	for _, item := range names {
+		for _, name := range strings.Split(item, ",") {
+			method := strings.ToUpper(strings.TrimSpace(name))
+			switch method {
+			case "":
+				continue
+			case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead:
+				methods = append(methods, method)
+			default:
+				return nil, fmt.Errorf("unsupported method %q", name)
+			}
+		}
+	}
+
+	if len(methods) == 0 {
+		return []string{http.MethodPost}, nil
+	}
+	return methods, nil
+}
+
+// Allows reports whether the method is accepted by the webhook.
+func (s *Spec) Allows(method string) bool {
+	for _, allowed := range s.Methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterActivation claims the webhook paths of an activation.
+// Fails if a path is already owned by another workflow.
+func RegisterActivation(client *RedisClient.Client, act *activation.Activation) error {
+	routes := make(map[string]Route)
+	for _, trigger := range act.TriggersOfType(TriggerNodeType) {
+		spec, err := ParseSpec(trigger.Data)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", trigger.NodeID, err)
+		}
+		if _, taken := routes[spec.Path]; taken {
+			return fmt.Errorf("node %s: path %q is used by several triggers", trigger.NodeID, spec.Path)
+		}
+		routes[spec.Path] = Route{WorkflowID: act.WorkflowID, NodeID: trigger.NodeID}
+	}
+
+	// Each path is claimed atomically: of two workflows racing for a path, one
+	// gets a conflict. Paths claimed before a conflict are released.
+	claimed := make([]string, 0, len(routes))
+	for path, route := range routes {
+		data, err := json.Marshal(route)
+		if err != nil {
+			releasePaths(client, claimed)
+			return fmt.Errorf("failed to marshal webhook route: %w", err)
+		}
+		ok, err := client.HSetNX(RoutesKey, path, string(data))
+		if err != nil {
+			releasePaths(client, claimed)
+			return err
+		}
+		if ok {
+			claimed = append(claimed, path)
+			continue
+		}
+
+		existing, err := Lookup(client, path)
+		if err != nil {
+			releasePaths(client, claimed)
+			return fmt.Errorf("failed to read webhook route %q: %w", path, err)
+		}
+		if existing.WorkflowID != act.WorkflowID {
+			releasePaths(client, claimed)
+			return &ConflictError{Path: path, WorkflowID: existing.WorkflowID}
+		}
+		// Already owned by this workflow: the trigger node may have changed
+		if err := client.HSet(RoutesKey, path, string(data)); err != nil {
+			releasePaths(client, claimed)
+			return err
+		}
+	}
+	return nil
+}
+
+// releasePaths drops the paths claimed by a registration that failed.
+func releasePaths(client *RedisClient.Client, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	if _, err := client.HDel(RoutesKey, paths...); err != nil {
+		logger.Log.Warn("Failed to release webhook paths", zap.Strings("paths", paths), zap.Error(err))
+	}
+}
+
+// UnregisterActivation releases the webhook paths owned by an activation.
+func UnregisterActivation(client *RedisClient.Client, act *activation.Activation) error {
+	for _, trigger := range act.TriggersOfType(TriggerNodeType) {
+		spec, err := ParseSpec(trigger.Data)
+		if err != nil {
+			continue // Never registered
+		}
+		if existing, err := Lookup(client, spec.Path); err == nil && existing.WorkflowID == act.WorkflowID {
+			if _, err := client.HDel(RoutesKey, spec.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup returns the route registered for a path.
+func Lookup(client *RedisClient.Client, path string) (*Route, error) {
+	raw, err := client.HGet(RoutesKey, NormalizePath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var route Route
+	if err := json.Unmarshal([]byte(raw), &route); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook route: %w", err)
+	}
+	return &route, nil
+}
+
+// ConflictError reports a webhook path already owned by another workflow.
+type ConflictError struct {
+	Path       string
+	WorkflowID string
+}
+
+// Error implements the error interface.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("webhook path %q is already used by workflow %s", e.Path, e.WorkflowID)
+}
+
+// RequestInput converts an incoming request into the run input of the trigger.
+// JSON bodies are decoded, any other body is passed as a string.
+func RequestInput(r *http.Request, path string) (map[string]interface{}, error) {
+	headers := make(map[string]interface{}, len(r.Header))
+	for name, values := range r.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ", ")
+	}
+
+	query := make(map[string]interface{}, len(r.URL.Query()))
+	for name, values := range r.URL.Query() {
+		if len(values) == 1 {
+			query[name] = values[0]
+		} else {
+			query[name] = values
+		}
+	}
+
+	var body interface{}
+	if r.Body != nil {
+		raw, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if len(raw) > MaxBodySize {
+			return nil, fmt.Errorf("request body exceeds %d bytes", MaxBodySize)
+		}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &body); err != nil {
+				body = string(raw)
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"method":     r.Method,
+		"path":       path,
+		"headers":    headers,
+		"query":      query,
+		"body":       body,
+		"receivedAt": time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// runOutcome is the subset of the worker's execution result needed to answer a webhook.
+type runOutcome struct {
+	Status string  `json:"status"`
+	Error  *string `json:"error,omitempty"`
+	Nodes  []struct {
+		Result json.RawMessage `json:"result"`
+	} `json:"nodes"`
+}
+
+// LastNodeOutput extracts the output of the last executed node from a run result.
+// Returns an error carrying the run error message if the run failed.
+func LastNodeOutput(rawResult string) (json.RawMessage, error) {
+	var outcome runOutcome
+	if err := json.Unmarshal([]byte(rawResult), &outcome); err != nil {
+		return nil, fmt.Errorf("failed to decode run result: %w", err)
+	}
+
+	if outcome.Status != "success" {
+		msg := "workflow run failed"
+		if outcome.Error != nil {
+			msg = *outcome.Error
+		}
+		return nil, fmt.Errorf("%s", msg)
+	}
+
+	if len(outcome.Nodes) == 0 {
+		return json.RawMessage("null"), nil
+	}
+	return outcome.Nodes[len(outcome.Nodes)-1].Result, nil
+}
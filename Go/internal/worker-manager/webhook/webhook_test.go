@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		want    *Spec
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			data: map[string]interface{}{"path": "orders/new"},
+			want: &Spec{Path: "orders/new", Methods: []string{"POST"}, Mode: ModeAsync, Timeout: DefaultTimeout},
+		},
+		{
+			name: "surrounding slashes",
+			data: map[string]interface{}{"path": "/orders/new/"},
+			want: &Spec{Path: "orders/new", Methods: []string{"POST"}, Mode: ModeAsync, Timeout: DefaultTimeout},
+		},
+		{
+			name: "every option",
+			data: map[string]interface{}{"path": "orders", "methods": []interface{}{"get", "PUT"}, "mode": "sync", "timeout": "20s"},
+			want: &Spec{Path: "orders", Methods: []string{"GET", "PUT"}, Mode: ModeSync, Timeout: 20 * time.Second},
+		},
+		{
+			name: "comma separated methods",
+			data: map[string]interface{}{"path": "orders", "methods": "GET, POST"},
+			want: &Spec{Path: "orders", Methods: []string{"GET", "POST"}, Mode: ModeAsync, Timeout: DefaultTimeout},
+		},
+		{
+			name: "empty methods",
+			data: map[string]interface{}{"path": "orders", "methods": []interface{}{}},
+			want: &Spec{Path: "orders", Methods: []string{"POST"}, Mode: ModeAsync, Timeout: DefaultTimeout},
+		},
+		{
+			name: "timeout in milliseconds",
+			data: map[string]interface{}{"path": "orders", "timeout": 1500.0},
+			want: &Spec{Path: "orders", Methods: []string{"POST"}, Mode: ModeAsync, Timeout: 1500 * time.Millisecond},
+		},
+		{
+			name: "blank timeout",
+			data: map[string]interface{}{"path": "orders", "timeout": ""},
+			want: &Spec{Path: "orders", Methods: []string{"POST"}, Mode: ModeAsync, Timeout: DefaultTimeout},
+		},
+		{name: "missing path", data: map[string]interface{}{}, wantErr: true},
+		{name: "root path", data: map[string]interface{}{"path": "/"}, wantErr: true},
+		{name: "invalid path", data: map[string]interface{}{"path": "orders?id=1"}, wantErr: true},
+		{name: "empty path segment", data: map[string]interface{}{"path": "orders//new"}, wantErr: true},
+		{name: "unsupported method", data: map[string]interface{}{"path": "orders", "methods": []interface{}{"CONNECT"}}, wantErr: true},
+		{name: "invalid mode", data: map[string]interface{}{"path": "orders", "mode": "later"}, wantErr: true},
+		{name: "mode is case-sensitive", data: map[string]interface{}{"path": "orders", "mode": "SYNC"}, wantErr: true},
+		{name: "timeout above the maximum", data: map[string]interface{}{"path": "orders", "timeout": "30s"}, wantErr: true},
+		{name: "zero timeout", data: map[string]interface{}{"path": "orders", "timeout": 0.0}, wantErr: true},
+		{name: "invalid timeout", data: map[string]interface{}{"path": "orders", "timeout": "soon"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSpec(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSpec(%v) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSpec(%v) = %+v, want %+v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecAllows(t *testing.T) {
+	spec := &Spec{Methods: []string{"GET", "POST"}}
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"GET", true},
+		{"POST", true},
+		{"PUT", false},
+		{"get", false}, // Request methods are canonical
+	}
+	for _, tt := range tests {
+		if got := spec.Allows(tt.method); got != tt.want {
+			t.Errorf("Allows(%s) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+}
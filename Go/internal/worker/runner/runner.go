@@ -2,19 +2,23 @@ package runner
 
 import (
 	"XKA/internal/shared/builder"
+	"XKA/internal/shared/control"
+	"XKA/internal/shared/nodetypes"
+	"XKA/internal/shared/queue"
+	"XKA/internal/shared/runlog"
+	"XKA/internal/shared/schema"
 	"XKA/pkg/RedisClient"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 	"time"
 )
 
 type NodeResponse struct {
 	NodeID     string `json:"nodeId"`
 	NodeType   string `json:"nodeType"`
-	Status     string `json:"status"`     // "success", "error", "skipped"
+	Status     string `json:"status"`     // "success", "error", "skipped", "reused", "pinned", "waiting"
 	Timestamp  int64  `json:"timestamp"`  // Heure de début d'exécution (Unix)
 	DurationMs int64  `json:"durationMs"` // Durée en millisecondes
 
@@ -22,13 +26,17 @@ type NodeResponse struct {
 	Error  *string     `json:"error,omitempty"`
 	Logs   []string    `json:"logs,omitempty"`
 	Meta   interface{} `json:"meta,omitempty"`
+	Branch string      `json:"branch,omitempty"` // Sortie empruntée (vide = sortie par défaut)
+
+	onLog func(line string) // Diffusion des logs en direct (journal de l'exécution)
 }
 
 // WorkflowExecutionResult structure pour capturer le résultat global du workflow
 type WorkflowExecutionResult struct {
 
+	RunID      string                 `json:"runId"` // Identifiant unique de l'exécution
 	WorkflowID string                 `json:"workflowId"`
-	Status     string                 `json:"status"` // "success", "error", "running", "skipped"
+	Status     string                 `json:"status"` // "success", "error", "running", "skipped", "cancelled", "waiting", "paused"
 	StartedAt  int64                  `json:"startedAt"`
 	EndedAt    int64                  `json:"endedAt"`
 	DurationMs int64                  `json:"durationMs"`
@@ -37,15 +45,50 @@ type WorkflowExecutionResult struct {
 	Error      *string                `json:"error,omitempty"`
 	Meta       map[string]interface{} `json:"meta,omitempty"`
 	NumbreOfNodes int                 `json:"numberOfNodes"` 
+	TriggerNodeID string              `json:"triggerNodeId,omitempty"` // Node du déclencheur ayant lancé l'exécution
+	TriggerType   string              `json:"triggerType,omitempty"`   // Type de déclencheur (manual, cron, webhook, event)
+	Waiting       *Suspension         `json:"waiting,omitempty"`       // Attente en cours lorsque l'exécution est suspendue ou en pause
+}
+
+// Suspension décrit l'événement attendu par une exécution suspendue
+// Une exécution mise en pause par un opérateur est suspendue avant sa prochaine node (Paused)
+type Suspension struct {
+	NodeID         string `json:"nodeId"`
+	NodeType       string `json:"nodeType"`
+	Position       int    `json:"position"`
+	Paused         bool   `json:"paused,omitempty"`         // Pause de l'opérateur : la node n'a pas encore été exécutée
+	CorrelationKey string `json:"correlationKey,omitempty"` // Clé que doit porter l'événement (vide = toute clé)
+	TimeoutAt      int64  `json:"timeoutAt,omitempty"`      // Échéance (Unix ms), 0 = aucune
+}
+
+// RunContext regroupe les informations d'une exécution partagées avec les exécuteurs
+type RunContext struct {
+	RunID         string                     // Identifiant de l'exécution
+	WorkflowID    string                     // Identifiant du workflow exécuté
+	TriggerNodeID string                     // Node de départ (vide = première start node)
+	Input         map[string]interface{}     // Données fournies par le déclencheur (paramètres validés pour un lancement manuel)
+	Recorder      *runlog.Recorder           // Journal d'événements de l'exécution (nil = aucun)
+	Control       *control.Gate              // Commandes de l'opérateur (nil = aucune)
+	RetryOf       string                     // Exécution d'origine d'une relance
+	Reuse         map[string]json.RawMessage // Réponses enregistrées réutilisées par une relance, par node
+	Development   bool                       // Exécution de développement : les nodes épinglées ne sont pas exécutées
+	Debug         bool                       // Exécution de débogage : pause avant les points d'arrêt
+	Breakpoints   map[string]bool            // Points d'arrêt supplémentaires de l'exécution de débogage
+	Restore       []json.RawMessage          // Réponses des nodes exécutées avant la suspension, dans l'ordre
+	Resume        *queue.Resolution          // Issue de l'attente dont reprend l'exécution
+	Resumed       bool                       // Reprise d'une exécution suspendue ou mise en pause
+
+	suspension *Suspension // Attente demandée par la node en cours
+	resuming   bool        // La reprise n'a pas encore été enregistrée
 }
 
 // NodeExecutor interface pour les exécuteurs de nodes
 type NodeExecutor interface {
-	Execute(node *builder.Node) (*NodeResponse, error)
+	Execute(rc *RunContext, node *builder.Node) (*NodeResponse, error)
 }
 
 // ExecuteFunc représente la fonction métier d'un exécuteur
-type ExecuteFunc func(node *builder.Node, resp *NodeResponse) error
+type ExecuteFunc func(rc *RunContext, node *builder.Node, resp *NodeResponse) error
 
 // BaseExecutor est un wrapper qui gère toutes les tâches communes
 type BaseExecutor struct {
@@ -59,8 +102,25 @@ func NewBaseExecutor(executeFunc ExecuteFunc) *BaseExecutor {
 	}
 }
 
+// ConfigExecuteFunc représente la fonction métier d'un exécuteur à configuration typée
+type ConfigExecuteFunc[C any] func(rc *RunContext, node *builder.Node, config *C, resp *NodeResponse) error
+
+// NewConfigExecutor crée un BaseExecutor dont la configuration (node.Data) est décodée
+// et validée dans la structure C (voir schema.Decode) avant d'appeler la fonction métier
+func NewConfigExecutor[C any](executeFunc ConfigExecuteFunc[C]) *BaseExecutor {
+	schema.Reflect(new(C)) // Panique dès l'enregistrement si la structure est invalide
+
+	return NewBaseExecutor(func(rc *RunContext, node *builder.Node, resp *NodeResponse) error {
+		var config C
+		if errs := schema.Decode(node.Data, &config); len(errs) > 0 {
+			return fmt.Errorf("invalid configuration: %s", errs.Error())
+		}
+		return executeFunc(rc, node, &config, resp)
+	})
+}
+
 // Execute implémente NodeExecutor et gère toute la logique commune
-func (be *BaseExecutor) Execute(node *builder.Node) (*NodeResponse, error) {
+func (be *BaseExecutor) Execute(rc *RunContext, node *builder.Node) (*NodeResponse, error) {
 	if node == nil {
 		return nil, fmt.Errorf("node is nil")
 	}
@@ -73,13 +133,17 @@ func (be *BaseExecutor) Execute(node *builder.Node) (*NodeResponse, error) {
 		NodeType:  node.Type,
 		Status:    "running",
 		Timestamp: start.Unix(),
-		Logs:      []string{fmt.Sprintf("Executing %s node: %s", node.Type, node.ID)},
+		Logs:      []string{},
 		Meta:      make(map[string]interface{}),
 		Result:    make(map[string]interface{}),
 	}
+	if rc != nil && rc.Recorder != nil {
+		resp.onLog = rc.Recorder.NodeLog
+	}
+	resp.AddLog("Executing %s node: %s", node.Type, node.ID)
 
 	// Exécution de la logique métier
-	err := be.executeFunc(node, resp)
+	err := be.executeFunc(rc, node, resp)
 
 	// Gestion automatique des erreurs
 	if err != nil {
@@ -87,7 +151,7 @@ func (be *BaseExecutor) Execute(node *builder.Node) (*NodeResponse, error) {
 		resp.Status = "error"
 		resp.Error = &fullMsg
 		resp.DurationMs = time.Since(start).Milliseconds()
-		resp.Logs = append(resp.Logs, "ERROR: "+err.Error())
+		resp.AddLog("ERROR: %s", err.Error())
 		return resp, fmt.Errorf("%s", fullMsg)
 	}
 
@@ -102,7 +166,11 @@ func (be *BaseExecutor) Execute(node *builder.Node) (*NodeResponse, error) {
 
 // Fonction helper pour ajouter des logs facilement
 func (resp *NodeResponse) AddLog(format string, args ...interface{}) {
-	resp.Logs = append(resp.Logs, fmt.Sprintf(format, args...))
+	line := fmt.Sprintf(format, args...)
+	resp.Logs = append(resp.Logs, line)
+	if resp.onLog != nil {
+		resp.onLog(line)
+	}
 }
 
 // Fonction helper pour définir le résultat
@@ -142,40 +210,102 @@ func NewWorkflowRunner() *WorkflowRunner {
 		executors: make(map[string]NodeExecutor),
 	}
 
-	// Enregistrer les exécuteurs simplifiés
-	runner.RegisterExecutor("manualStartNode", NewBaseExecutor(executeManualStart))
-	runner.RegisterExecutor("httpRequestNode", NewBaseExecutor(executeHttpRequest))
-	runner.RegisterExecutor("waitingNode", NewBaseExecutor(executeWaiting))
+	// Enregistrer les exécuteurs simplifiés (un par type du registre nodetypes,
+	// qui alimente aussi la validation et le catalogue de l'éditeur)
+	runner.RegisterExecutor(nodetypes.ManualStartNode, NewBaseExecutor(executeManualStart))
+	runner.RegisterExecutor(nodetypes.CronTriggerNode, NewBaseExecutor(executeCronTrigger))
+	runner.RegisterExecutor(nodetypes.WebhookTriggerNode, NewBaseExecutor(executeWebhookTrigger))
+	runner.RegisterExecutor(nodetypes.EventTriggerNode, NewConfigExecutor(executeEventTrigger))
+	runner.RegisterExecutor(nodetypes.HttpRequestNode, NewConfigExecutor(executeHttpRequest))
+	runner.RegisterExecutor(nodetypes.WaitingNode, NewConfigExecutor(executeWaiting))
+	runner.RegisterExecutor(nodetypes.ApprovalNode, NewConfigExecutor(executeApproval))
+	runner.RegisterExecutor(nodetypes.WaitForEventNode, NewConfigExecutor(executeWaitForEvent))
 
 	return runner
 }
 
-// RegisterExecutor enregistre un exécuteur pour un type de node
+// RegisterExecutor enregistre un exécuteur pour un type de node.
+// Le type doit être déclaré dans le registre nodetypes (panique sinon) : un exécuteur
+// sans déclaration ne serait ni validé ni proposé dans l'éditeur.
 func (wr *WorkflowRunner) RegisterExecutor(nodeType string, executor NodeExecutor) {
+	if _, ok := nodetypes.Lookup(nodeType); !ok {
+		panic(fmt.Sprintf("runner: executor registered for undeclared node type %s", nodeType))
+	}
 	wr.executors[nodeType] = executor
 }
 
 // executeManualStart - logique métier simplifiée pour le démarrage manuel
-func executeManualStart(node *builder.Node, resp *NodeResponse) error {
+func executeManualStart(rc *RunContext, node *builder.Node, resp *NodeResponse) error {
 	resp.AddLog("Starting workflow from node: %s", node.ID)
 	resp.SetResult("message", "Workflow started successfully")
+	// Paramètres du run, déjà validés (et complétés par les valeurs par défaut) par le manager
+	if len(rc.Input) > 0 {
+		resp.SetResult("input", rc.Input)
+	}
 	return nil
 }
 
-// executeHttpRequest - logique métier simplifiée pour les requêtes HTTP
-func executeHttpRequest(node *builder.Node, resp *NodeResponse) error {
-	// Validation des paramètres
-	url, urlOk := node.Data["url"].(string)
-	method, methodOk := node.Data["method"].(string)
-	if !urlOk || !methodOk || url == "" || method == "" {
-		return fmt.Errorf("missing URL or method")
+// executeCronTrigger - expose l'heure de déclenchement fournie par le scheduler
+func executeCronTrigger(rc *RunContext, node *builder.Node, resp *NodeResponse) error {
+	firedAt, _ := rc.Input["firedAt"].(string)
+	if firedAt == "" {
+		// Exécution hors scheduler (ex: lancement manuel) : on utilise l'heure courante
+		firedAt = time.Now().UTC().Format(time.RFC3339)
+		resp.AddLog("No fire time provided, using current time")
+	}
+
+	resp.AddLog("Cron trigger fired at %s", firedAt)
+	resp.SetResult("firedAt", firedAt)
+	for _, key := range []string{"expression", "timezone"} {
+		if value, ok := rc.Input[key]; ok {
+			resp.SetResult(key, value)
+		}
+	}
+	return nil
+}
+
+// executeWebhookTrigger - expose la requête HTTP entrante reçue par le worker manager
+func executeWebhookTrigger(rc *RunContext, node *builder.Node, resp *NodeResponse) error {
+	method, _ := rc.Input["method"].(string)
+	if method == "" {
+		resp.AddLog("No incoming request provided, starting with empty input")
+	} else {
+		resp.AddLog("Webhook received: %s %v", method, rc.Input["path"])
 	}
 
+	for _, key := range []string{"method", "path", "headers", "query", "body"} {
+		if value, ok := rc.Input[key]; ok {
+			resp.SetResult(key, value)
+		}
+	}
+	return nil
+}
+
+// executeEventTrigger - expose l'événement publié via l'API
+func executeEventTrigger(rc *RunContext, node *builder.Node, config *nodetypes.EventTriggerConfig, resp *NodeResponse) error {
+	event, _ := rc.Input["event"].(string)
+	if event == "" {
+		event = config.Event
+		resp.AddLog("No event provided, starting with empty payload")
+	} else {
+		resp.AddLog("Event received: %s", event)
+	}
+
+	resp.SetResult("event", event)
+	resp.SetResult("payload", rc.Input["payload"])
+	if receivedAt, ok := rc.Input["receivedAt"]; ok {
+		resp.SetResult("receivedAt", receivedAt)
+	}
+	return nil
+}
+
+// executeHttpRequest - logique métier simplifiée pour les requêtes HTTP
+func executeHttpRequest(rc *RunContext, node *builder.Node, config *nodetypes.HttpRequestConfig, resp *NodeResponse) error {
 	// Configuration du client HTTP
 	client := &http.Client{Timeout: 30 * time.Second}
 
 	// Création de la requête
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequest(config.Method, config.URL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
@@ -202,8 +332,8 @@ func executeHttpRequest(node *builder.Node, resp *NodeResponse) error {
 	resp.SetResult("statusCode", httpResp.StatusCode)
 	resp.SetResult("body", string(body))
 	resp.SetResult("headers", httpResp.Header)
-	resp.SetResult("url", url)
-	resp.SetResult("method", method)
+	resp.SetResult("url", config.URL)
+	resp.SetResult("method", config.Method)
 
 	resp.SetMeta("contentLength", len(body))
 	resp.SetMeta("contentType", httpResp.Header.Get("Content-Type"))
@@ -214,26 +344,12 @@ func executeHttpRequest(node *builder.Node, resp *NodeResponse) error {
 }
 
 // executeWaiting - logique métier simplifiée pour l'attente
-func executeWaiting(node *builder.Node, resp *NodeResponse) error {
-	// Extraction et validation de la durée
-	durationVal, ok := node.Data["duration"]
-	if !ok {
-		return fmt.Errorf("missing 'duration' parameter")
-	}
-
-	durationStr, ok := durationVal.(string)
-	if !ok {
-		return fmt.Errorf("duration must be a string")
-	}
-
-	waitMs, err := strconv.ParseInt(durationStr, 10, 64)
-	if err != nil || waitMs <= 0 {
-		return fmt.Errorf("invalid duration value")
-	}
+func executeWaiting(rc *RunContext, node *builder.Node, config *nodetypes.WaitingConfig, resp *NodeResponse) error {
+	waitMs := config.Duration.Milliseconds()
 
 	// Attente
 	resp.AddLog("Waiting %dms...", waitMs)
-	time.Sleep(time.Duration(waitMs) * time.Millisecond)
+	time.Sleep(config.Duration)
 
 	// Configuration des résultats
 	resp.SetResult("waitedMs", waitMs)
@@ -243,8 +359,127 @@ func executeWaiting(node *builder.Node, resp *NodeResponse) error {
 	return nil
 }
 
+// executeWaitForEvent - suspend l'exécution jusqu'à la réception d'un événement externe
+func executeWaitForEvent(rc *RunContext, node *builder.Node, config *nodetypes.WaitForEventConfig, resp *NodeResponse) error {
+	resolution := rc.resolution(node)
+	if resolution == nil {
+		return suspend(rc, node, resp, config.CorrelationKey, config.Timeout)
+	}
+	if resolution.Outcome == queue.OutcomeTimeout {
+		return timedOut(node, resp)
+	}
+
+	var payload interface{}
+	if len(resolution.Payload) > 0 {
+		json.Unmarshal(resolution.Payload, &payload)
+	}
+	resp.AddLog("Event received")
+	resp.SetResult("event", config.Event)
+	resp.SetResult("payload", payload)
+	resp.SetResult("receivedAt", time.UnixMilli(resolution.ReceivedAt).UTC().Format(time.RFC3339))
+	return nil
+}
+
+// executeApproval - suspend l'exécution jusqu'à la décision d'une personne
+// Un refus emprunte la sortie "rejected" si elle est reliée, sinon la node échoue
+func executeApproval(rc *RunContext, node *builder.Node, config *nodetypes.ApprovalConfig, resp *NodeResponse) error {
+	resolution := rc.resolution(node)
+	if resolution == nil {
+		if config.Message != "" {
+			resp.SetResult("message", config.Message)
+		}
+		return suspend(rc, node, resp, config.CorrelationKey, config.Timeout)
+	}
+	if resolution.Outcome == queue.OutcomeTimeout {
+		return timedOut(node, resp)
+	}
+
+	var decision struct {
+		Approved bool   `json:"approved"`
+		By       string `json:"by"`
+		Comment  string `json:"comment"`
+	}
+	if len(resolution.Payload) > 0 {
+		if err := json.Unmarshal(resolution.Payload, &decision); err != nil {
+			return fmt.Errorf("invalid approval decision: %v", err)
+		}
+	}
+
+	resp.SetResult("approved", decision.Approved)
+	resp.SetResult("by", decision.By)
+	resp.SetResult("comment", decision.Comment)
+	resp.SetResult("decidedAt", time.UnixMilli(resolution.ReceivedAt).UTC().Format(time.RFC3339))
+
+	if decision.Approved {
+		resp.AddLog("Approved by %s", decisionAuthor(decision.By))
+		return nil
+	}
+	resp.AddLog("Rejected by %s", decisionAuthor(decision.By))
+	if !node.HasBranch(nodetypes.BranchRejected) {
+		return fmt.Errorf("approval rejected by %s", decisionAuthor(decision.By))
+	}
+	resp.Branch = nodetypes.BranchRejected
+	return nil
+}
+
+// decisionAuthor retourne l'auteur d'une décision pour les logs
+func decisionAuthor(by string) string {
+	if by == "" {
+		return "an anonymous approver"
+	}
+	return by
+}
+
+// suspend met la node en attente : l'exécution s'arrête et libère le worker
+// key : clé de corrélation attendue (optionnelle), timeout : délai avant la sortie "timeout" (0 = aucun)
+func suspend(rc *RunContext, node *builder.Node, resp *NodeResponse, key string, timeout time.Duration) error {
+	var timeoutAt int64
+	if timeout > 0 {
+		timeoutAt = time.Now().Add(timeout).UnixMilli()
+	}
+
+	rc.suspension = &Suspension{
+		NodeID:         node.ID,
+		NodeType:       node.Type,
+		CorrelationKey: key,
+		TimeoutAt:      timeoutAt,
+	}
+
+	resp.Status = "waiting"
+	if key != "" {
+		resp.SetResult("correlationKey", key)
+	}
+	if timeoutAt > 0 {
+		resp.SetResult("timeoutAt", time.UnixMilli(timeoutAt).UTC().Format(time.RFC3339))
+		resp.AddLog("Waiting for an event until %s", time.UnixMilli(timeoutAt).UTC().Format(time.RFC3339))
+	} else {
+		resp.AddLog("Waiting for an event")
+	}
+	return nil
+}
+
+// timedOut termine une attente expirée : la sortie "timeout" est empruntée si elle est reliée, sinon la node échoue
+func timedOut(node *builder.Node, resp *NodeResponse) error {
+	resp.SetResult("timedOut", true)
+	if !node.HasBranch(nodetypes.BranchTimeout) {
+		return fmt.Errorf("timed out waiting for an event")
+	}
+	resp.AddLog("Timed out waiting for an event, taking the timeout branch")
+	resp.Branch = nodetypes.BranchTimeout
+	return nil
+}
+
+// resolution retourne l'issue de l'attente d'une node lors de la reprise de l'exécution, nil sinon
+func (rc *RunContext) resolution(node *builder.Node) *queue.Resolution {
+	if rc == nil || rc.Resume == nil || rc.Resume.NodeID != node.ID {
+		return nil
+	}
+	return rc.Resume
+}
+
 func buildWorkflowExecutionResult(wf *builder.Workflow, runID string, status string, errorMsg string) *WorkflowExecutionResult {
 	result := &WorkflowExecutionResult{
+		RunID:      runID,
 		WorkflowID: wf.ID,
 		Status:     status,
 		StartedAt:  time.Now().Unix(),
@@ -266,8 +501,32 @@ func buildWorkflowExecutionResult(wf *builder.Workflow, runID string, status str
 }
 
 // Run exécute un workflow avec une seule node de départ et retourne les résultats
-func (wr *WorkflowRunner) Run(wf *builder.Workflow, runID string) (*WorkflowExecutionResult, error) {
+// La progression est enregistrée dans le journal de l'exécution au fil des nodes
+func (wr *WorkflowRunner) Run(wf *builder.Workflow, rc *RunContext) (*WorkflowExecutionResult, error) {
+	if rc == nil {
+		rc = &RunContext{}
+	}
+
+	result, err := wr.execute(wf, rc)
+	if result == nil {
+		return result, err
+	}
+	if result.Waiting != nil && result.Waiting.Paused {
+		return result, err // La pause a été enregistrée avant la node en attente
+	}
+	if result.Waiting != nil {
+		waiting := result.Nodes[result.Waiting.Position]
+		rc.Recorder.RunWaiting(result.header(), result.Waiting.Position, waiting.NodeID, waiting.NodeType, &waiting)
+	} else {
+		rc.Recorder.RunFinished(result.header())
+	}
+	return result, err
+}
+
+// execute parcourt le graphe depuis la node du déclencheur
+func (wr *WorkflowRunner) execute(wf *builder.Workflow, rc *RunContext) (*WorkflowExecutionResult, error) {
 	startTime := time.Now()
+	runID := rc.RunID
 
 	if wf == nil {
 		errorMsg := "workflow is nil"
@@ -281,9 +540,39 @@ func (wr *WorkflowRunner) Run(wf *builder.Workflow, runID string) (*WorkflowExec
 	}
 
 	result := buildWorkflowExecutionResult(wf, runID, "running", "")
+	rc.WorkflowID = wf.ID
 
-	firstNodeID := wf.StartNodeIDs[0]
+	// Démarrer uniquement depuis la node du déclencheur ayant lancé l'exécution
+	startNode, err := wf.SelectStartNode(rc.TriggerNodeID)
+	if err != nil {
+		errorMsg := err.Error()
+		result.Status = "error"
+		result.Error = &errorMsg
+		return result, fmt.Errorf("%s", errorMsg)
+	}
+	firstNodeID := startNode.ID
+	rc.TriggerNodeID = firstNodeID
+	result.TriggerNodeID = firstNodeID
+	result.TriggerType = string(nodetypes.TriggerKindOf(startNode.Type))
 	result.GlobalLogs = append(result.GlobalLogs, fmt.Sprintf("Starting workflow execution with node: %s", firstNodeID))
+	if rc.Development {
+		result.Meta["mode"] = "development"
+	}
+	if rc.RetryOf != "" {
+		result.Meta["retryOf"] = rc.RetryOf
+		result.GlobalLogs = append(result.GlobalLogs, fmt.Sprintf("Retry of run %s, reusing %d recorded node responses", rc.RetryOf, len(rc.Reuse)))
+	}
+	// Reprise après une attente ou une pause : l'exécution a déjà été annoncée
+	switch {
+	case !rc.Resumed:
+		rc.Recorder.RunStarted(result.header())
+	case rc.Resume != nil:
+		result.GlobalLogs = append(result.GlobalLogs, fmt.Sprintf("Resuming run at node %s (%s), restoring %d node responses", rc.Resume.NodeID, rc.Resume.Outcome, len(rc.Restore)))
+	default:
+		result.GlobalLogs = append(result.GlobalLogs, fmt.Sprintf("Resuming paused run, restoring %d node responses", len(rc.Restore)))
+	}
+	rc.resuming = rc.Resumed
+	restored := restoredResponses(rc)
 
 	// Créer une queue avec la première node
 	queue := []string{firstNodeID}
@@ -304,12 +593,76 @@ func (wr *WorkflowRunner) Run(wf *builder.Workflow, runID string) (*WorkflowExec
 			return result, fmt.Errorf("%s", errorMsg)
 		}
 
-		nodeResponse, err := wr.executeNode(node)
+		position := len(result.Nodes)
+
+		// Reprise : les nodes exécutées avant la suspension sont restaurées sans être rejouées ni enregistrées
+		if pending := restored[node.ID]; len(pending) > 0 {
+			restored[node.ID] = pending[1:]
+			result.Nodes = append(result.Nodes, pending[0])
+			queue = append(queue, node.BranchNextIDs(pending[0].Branch)...)
+			continue
+		}
+
+		// Relance : une node déjà exécutée par l'exécution d'origine n'est pas rejouée
+		// Développement : une node épinglée fournit sa sortie épinglée sans être exécutée
+		substitute := reusedResponse(rc, node)
+		if substitute == nil {
+			substitute = pinnedResponse(rc, node)
+		}
+		if substitute != nil {
+			rc.Recorder.NodeStarted(position, node.ID, node.Type)
+			result.Nodes = append(result.Nodes, *substitute)
+			rc.Recorder.NodeFinished(position, node.ID, node.Type, substitute)
+			queue = append(queue, node.BranchNextIDs(substitute.Branch)...)
+			continue
+		}
+
+		// Commandes de l'opérateur (annulation, pause, pas à pas) prises en compte entre les nodes
+		switch wr.checkpoint(rc, position, node, result) {
+		case control.Cancel:
+			errorMsg := fmt.Sprintf("run cancelled before node %s", currentNodeID)
+			result.Status = "cancelled"
+			result.Error = &errorMsg
+			result.EndedAt = time.Now().Unix()
+			result.DurationMs = time.Since(startTime).Milliseconds()
+			result.GlobalLogs = append(result.GlobalLogs, errorMsg)
+			return result, nil
+		case control.Pause:
+			// Pause de l'opérateur : l'exécution est suspendue avant la node, le worker est libéré
+			result.Waiting = &Suspension{NodeID: node.ID, NodeType: node.Type, Position: position, Paused: true}
+			result.Status = "paused"
+			result.EndedAt = time.Now().Unix()
+			result.DurationMs = time.Since(startTime).Milliseconds()
+			result.GlobalLogs = append(result.GlobalLogs, fmt.Sprintf("Run paused before node %s", currentNodeID))
+			return result, nil
+		}
+
+		// Première node exécutée après la reprise
+		if rc.resuming {
+			rc.resuming = false
+			if rc.resolution(node) != nil {
+				rc.Recorder.WaitResolved(result.header(), position, node.ID, node.Type, rc.Resume)
+			} else {
+				rc.Recorder.RunResumed(position, node.ID, node.Type)
+			}
+		}
+		rc.Recorder.NodeStarted(position, node.ID, node.Type)
+
+		nodeResponse, err := wr.executeNode(rc, node)
 		if err != nil {
 			// Ajouter la réponse de la node même en cas d'erreur
-			if nodeResponse != nil {
-				result.Nodes = append(result.Nodes, *nodeResponse)
+			if nodeResponse == nil {
+				errorMsg := err.Error()
+				nodeResponse = &NodeResponse{
+					NodeID:    node.ID,
+					NodeType:  node.Type,
+					Status:    "error",
+					Timestamp: time.Now().Unix(),
+					Error:     &errorMsg,
+				}
 			}
+			result.Nodes = append(result.Nodes, *nodeResponse)
+			rc.Recorder.NodeFinished(position, node.ID, node.Type, nodeResponse)
 
 			errorMsg := fmt.Sprintf("node %s execution failed: %v", currentNodeID, err)
 			result.Status = "error"
@@ -320,15 +673,30 @@ func (wr *WorkflowRunner) Run(wf *builder.Workflow, runID string) (*WorkflowExec
 			return result, fmt.Errorf("%s", errorMsg)
 		}
 
+		// Attente d'un événement externe : l'exécution est suspendue, le worker est libéré
+		if nodeResponse != nil && rc.suspension != nil {
+			rc.suspension.Position = position
+			result.Nodes = append(result.Nodes, *nodeResponse)
+			result.Waiting = rc.suspension
+			result.Status = "waiting"
+			result.EndedAt = time.Now().Unix()
+			result.DurationMs = time.Since(startTime).Milliseconds()
+			result.GlobalLogs = append(result.GlobalLogs, fmt.Sprintf("Run suspended at node %s, waiting for an event", currentNodeID))
+			return result, nil
+		}
+
 		// Ajouter la réponse de la node aux résultats
 		if nodeResponse != nil {
 			result.Nodes = append(result.Nodes, *nodeResponse)
+			rc.Recorder.NodeFinished(position, node.ID, node.Type, nodeResponse)
 		}
 
-		result.publishResult()
-
-		// Ajouter les nodes suivantes à la queue
-		queue = append(queue, node.NextIDs...)
+		// Ajouter les nodes suivantes de la sortie empruntée à la queue
+		branch := ""
+		if nodeResponse != nil {
+			branch = nodeResponse.Branch
+		}
+		queue = append(queue, node.BranchNextIDs(branch)...)
 	}
 	// Finaliser les résultats
 	result.Status = "success"
@@ -340,38 +708,147 @@ func (wr *WorkflowRunner) Run(wf *builder.Workflow, runID string) (*WorkflowExec
 }
 
 
-func (wr *WorkflowExecutionResult) publishResult() error {
-	client := RedisClient.GetClient()
-	if client == nil {
-		return fmt.Errorf("redis client not initialized")
+// checkpoint applique les commandes de l'opérateur avant une node
+// En débogage, l'exécution se met elle-même en pause avant un point d'arrêt et
+// attend sur le worker les commandes suivantes (pas à pas, continuer)
+// Hors débogage, une pause retourne control.Pause : l'exécution est suspendue jusqu'à sa reprise
+// Une pause de débogage dépassant control.MaxDebugPause est suspendue de la même façon
+func (wr *WorkflowRunner) checkpoint(rc *RunContext, position int, node *builder.Node, result *WorkflowExecutionResult) control.Decision {
+	reason := "paused"
+	breakpoint := rc.Breakpoints[node.ID] || node.IsBreakpoint()
+	if rc.Debug && breakpoint && rc.resolution(node) == nil && rc.Control.Break() {
+		reason = "breakpoint"
+		result.GlobalLogs = append(result.GlobalLogs, fmt.Sprintf("Breakpoint reached before node: %s", node.ID))
 	}
-	wrJson, err := json.Marshal(wr)
 
-	if err != nil {
-		return fmt.Errorf("failed to marshal workflow result: %v", err)
+	paused := false
+	var pausedAt, refreshedAt time.Time
+	for {
+		switch rc.Control.Next() {
+		case control.Cancel:
+			return control.Cancel
+		case control.Continue:
+			if paused {
+				rc.Recorder.RunResumed(position, node.ID, node.Type)
+			}
+			return control.Continue
+		default:
+			if !paused {
+				paused = true
+				pausedAt, refreshedAt = time.Now(), time.Now()
+				rc.Recorder.RunPaused(position, node.ID, node.Type, map[string]interface{}{
+					"reason":   reason,
+					"nodeId":   node.ID,
+					"nodeType": node.Type,
+					"position": position,
+					"inputs":   resolvedInputs(rc, node, result),
+				})
+			}
+			if !rc.Debug {
+				return control.Pause
+			}
+			// Pause de débogage trop longue : le worker est libéré
+			if time.Since(pausedAt) >= control.MaxDebugPause {
+				result.GlobalLogs = append(result.GlobalLogs, fmt.Sprintf("Debug pause exceeded %s, run suspended before node: %s", control.MaxDebugPause, node.ID))
+				return control.Pause
+			}
+			// L'état de contrôle ne doit pas expirer pendant la pause
+			if time.Since(refreshedAt) >= control.RefreshInterval {
+				rc.Control.Refresh()
+				refreshedAt = time.Now()
+			}
+			time.Sleep(control.PollInterval)
+		}
 	}
-	
-	list_name := fmt.Sprintf("workflow:%s:results", wr.WorkflowID)
-	_, err = client.LPush(list_name, string(wrJson))
-	
-	if err != nil {
-		return fmt.Errorf("failed to publish workflow result to Redis: %v", err)
+}
+
+// restoredResponses indexe par node les réponses restaurées à la reprise d'une exécution suspendue
+// Une node exécutée plusieurs fois a plusieurs réponses, consommées dans l'ordre
+func restoredResponses(rc *RunContext) map[string][]NodeResponse {
+	restored := make(map[string][]NodeResponse, len(rc.Restore))
+	for _, raw := range rc.Restore {
+		var resp NodeResponse
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			continue
+		}
+		restored[resp.NodeID] = append(restored[resp.NodeID], resp)
 	}
-	err = client.SetExpire(list_name, 30*time.Minute)
-	
-	if err != nil {
-		return fmt.Errorf("failed to set expiration for Redis list: %v", err)
+	return restored
+}
+
+// reusedResponse retourne la réponse enregistrée d'une node par l'exécution d'origine d'une relance
+// nil si la node doit être exécutée
+func reusedResponse(rc *RunContext, node *builder.Node) *NodeResponse {
+	raw, ok := rc.Reuse[node.ID]
+	if !ok {
+		return nil
 	}
-	// client.LTrim(list_name, 0, 49)
-	// client.Expire(list_name, 24*time.Hour)
-	
-	return nil
+
+	var resp NodeResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil // Réponse illisible : la node est exécutée à nouveau
+	}
+	resp.NodeID = node.ID
+	resp.NodeType = node.Type
+	resp.Status = "reused"
+	resp.Error = nil
+	resp.SetMeta("reusedFrom", rc.RetryOf)
+	return &resp
+}
+
+// pinnedResponse retourne la sortie épinglée d'une node lors d'une exécution de développement
+// nil si la node doit être exécutée
+func pinnedResponse(rc *RunContext, node *builder.Node) *NodeResponse {
+	if !rc.Development {
+		return nil
+	}
+	pinned, ok := node.PinnedData()
+	if !ok {
+		return nil
+	}
+
+	resp := &NodeResponse{
+		NodeID:    node.ID,
+		NodeType:  node.Type,
+		Status:    "pinned",
+		Timestamp: time.Now().Unix(),
+		Result:    pinned,
+		Logs:      []string{},
+		Meta:      map[string]interface{}{"pinned": true},
+	}
+	resp.AddLog("Using pinned data, node %s not executed", node.ID)
+	return resp
 }
 
+// resolvedInputs décrit ce que reçoit une node en attente : sa configuration,
+// les données du déclencheur et la dernière réponse de chacune de ses nodes précédentes
+func resolvedInputs(rc *RunContext, node *builder.Node, result *WorkflowExecutionResult) map[string]interface{} {
+	previous := make(map[string]interface{}, len(node.PreviousIDs))
+	for _, previousID := range node.PreviousIDs {
+		for i := len(result.Nodes) - 1; i >= 0; i-- {
+			if result.Nodes[i].NodeID == previousID {
+				previous[previousID] = result.Nodes[i].Result
+				break
+			}
+		}
+	}
 
+	return map[string]interface{}{
+		"data":     node.Data,
+		"input":    rc.Input,
+		"previous": previous,
+	}
+}
+
+// header retourne le résultat sans les nodes, enregistré comme en-tête de l'exécution
+func (wr *WorkflowExecutionResult) header() *WorkflowExecutionResult {
+	header := *wr
+	header.Nodes = nil
+	return &header
+}
 
 // executeNode exécute une node individuelle et retourne sa réponse
-func (wr *WorkflowRunner) executeNode(node *builder.Node) (*NodeResponse, error) {
+func (wr *WorkflowRunner) executeNode(rc *RunContext, node *builder.Node) (*NodeResponse, error) {
 	if node == nil {
 		return nil, fmt.Errorf("node is nil")
 	}
@@ -381,13 +858,42 @@ func (wr *WorkflowRunner) executeNode(node *builder.Node) (*NodeResponse, error)
 		return nil, fmt.Errorf("no executor found for node type: %s", node.Type)
 	}
 
-	return executor.Execute(node)
+	return executor.Execute(rc, node)
 }
 
 // Fonction helper pour utilisation simple - mise à jour pour retourner les résultats
-func Run(wf *builder.Workflow, runID string) (*WorkflowExecutionResult, error) {
+func Run(wf *builder.Workflow, rc *RunContext) (*WorkflowExecutionResult, error) {
 	runner := NewWorkflowRunner()
-	result, err := runner.Run(wf, runID)
-	result.publishResult()
-	return result, err
+	if rc != nil && rc.RunID != "" {
+		if rc.Recorder == nil {
+			rc.Recorder = runlog.NewRecorder(RedisClient.GetClient(), rc.RunID)
+		}
+		if rc.Control == nil {
+			rc.Control = control.NewGate(RedisClient.GetClient(), rc.RunID)
+		}
+	}
+	return runner.Run(wf, rc)
+}
+
+// ExecuteNode exécute une node isolée (test d'une étape) avec les exécuteurs du runner
+// Une erreur d'exécution est rapportée dans la réponse, qui n'est jamais nil
+func ExecuteNode(node *builder.Node, rc *RunContext) *NodeResponse {
+	if rc == nil {
+		rc = &RunContext{}
+	}
+
+	resp, err := NewWorkflowRunner().executeNode(rc, node)
+	if err != nil && resp == nil {
+		errorMsg := err.Error()
+		resp = &NodeResponse{
+			Status:    "error",
+			Timestamp: time.Now().Unix(),
+			Error:     &errorMsg,
+			Logs:      []string{},
+		}
+		if node != nil {
+			resp.NodeID, resp.NodeType = node.ID, node.Type
+		}
+	}
+	return resp
 }
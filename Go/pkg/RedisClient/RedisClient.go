@@ -4,6 +4,7 @@ import (
 
 
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -26,21 +27,63 @@ type Client struct {
 var (
 	instance *Client
 	once     sync.Once
+
+	blockingInstance *Client
+	blockingOnce     sync.Once
+)
+
+// Taille des pools de connexions
+const (
+	defaultPoolSize         = 10  // Commandes courantes
+	defaultBlockingPoolSize = 200 // Lectures bloquantes (une connexion par appelant en attente)
 )
 
+// ErrTimeout est retourné par les opérations bloquantes lorsque le délai expire sans résultat
+var ErrTimeout = errors.New("timeout reached, no jobs available")
+
 // GetClient retourne l'instance singleton du client Redis
 func GetClient() *Client {
 	once.Do(func() {
 		instance = &Client{
 			ctx: context.Background(),
 		}
-		instance.initClient()
+		instance.initClient(defaultPoolSize, 5)
 	})
 	return instance
 }
 
+// GetBlockingClient retourne le client Redis réservé aux lectures bloquantes
+// (BRPOP / XREAD BLOCK des appelants qui attendent un résultat ou suivent une
+// exécution). Chaque attente garde une connexion pendant toute sa durée : un pool
+// séparé évite qu'elles privent les autres commandes de connexions.
+// Taille du pool : REDIS_BLOCKING_POOL_SIZE (200 par défaut).
+func GetBlockingClient() *Client {
+	blockingOnce.Do(func() {
+		poolSize := defaultBlockingPoolSize
+		if raw := os.Getenv("REDIS_BLOCKING_POOL_SIZE"); raw != "" {
+			fmt.Sscanf(raw, "%d", &poolSize)
+		}
+		blockingInstance = &Client{
+			ctx: context.Background(),
+		}
+		blockingInstance.initClient(poolSize, 0)
+	})
+	return blockingInstance
+}
+
+// WithContext retourne une copie du client dont les commandes utilisent ctx :
+// une lecture bloquante est interrompue (et sa connexion libérée) dès que ctx est annulé
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{rdb: c.rdb, ctx: ctx}
+}
+
+// isCancelled indique si l'erreur provient de l'annulation du contexte de l'appelant
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
 // initClient initialise la connexion Redis
-func (c *Client) initClient() {
+func (c *Client) initClient(poolSize, minIdleConns int) {
 	host := os.Getenv("REDIS_HOST")
 	if host == "" {
 		host = "localhost:6379" // valeur par défaut
@@ -59,8 +102,8 @@ func (c *Client) initClient() {
 		Addr:         host,
 		Password:     password,
 		DB:           db,
-		PoolSize:     10,                // nombre de connexions dans le pool
-		MinIdleConns: 5,                 // connexions inactives minimum
+		PoolSize:     poolSize,          // nombre de connexions dans le pool
+		MinIdleConns: minIdleConns,      // connexions inactives minimum
 		MaxRetries:   3,                 // nombre de tentatives de reconnexion
 		DialTimeout:  5 * time.Second,   // timeout de connexion
 		ReadTimeout:  3 * time.Second,   // timeout de lecture
@@ -96,6 +139,19 @@ func (c *Client) Set(key string, value interface{}, expiration time.Duration) er
 	return nil
 }
 
+// SetNX stocke une valeur uniquement si la clé n'existe pas encore.
+// Retourne true si la clé a été créée (utilisé comme verrou distribué).
+func (c *Client) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	ok, err := c.rdb.SetNX(c.ctx, key, value, expiration).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du SETNX",
+			zap.String("key", key),
+			zap.Error(err))
+		return false, fmt.Errorf("failed to SETNX key %s: %w", key, err)
+	}
+	return ok, nil
+}
+
 // Get récupère une valeur par sa clé
 func (c *Client) Get(key string) (string, error) {
 	val, err := c.rdb.Get(c.ctx, key).Result()
@@ -213,7 +269,10 @@ func (c *Client) BRPop(timeout time.Duration, keys ...string) ([]string, error)
 	result, err := c.rdb.BRPop(c.ctx, timeout, keys...).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return nil, fmt.Errorf("timeout reached, no jobs available")
+			return nil, ErrTimeout
+		}
+		if isCancelled(err) {
+			return nil, err
 		}
 		logger.Log.Error("Erreur lors du BRPOP", 
 			zap.Strings("keys", keys), 
@@ -250,6 +309,275 @@ func (c *Client) LRange(key string, start, stop int64) ([]string, error) {
 	return result, nil
 }
 
+// === OPÉRATIONS DE SORTED SETS POUR LA PLANIFICATION ===
+
+// ZAdd ajoute un membre à un sorted set avec le score donné
+func (c *Client) ZAdd(key string, score float64, member string) error {
+	err := c.rdb.ZAdd(c.ctx, key, redis.Z{Score: score, Member: member}).Err()
+	if err != nil {
+		logger.Log.Error("Erreur lors du ZADD",
+			zap.String("key", key),
+			zap.String("member", member),
+			zap.Error(err))
+		return fmt.Errorf("failed to ZADD to key %s: %w", key, err)
+	}
+	return nil
+}
+
+// ZRem retire un ou plusieurs membres d'un sorted set et retourne le nombre retiré.
+// Un retour de 1 permet de "réclamer" un membre de façon atomique entre plusieurs instances.
+func (c *Client) ZRem(key string, members ...string) (int64, error) {
+	if len(members) == 0 {
+		return 0, fmt.Errorf("no members provided for ZREM")
+	}
+
+	values := make([]interface{}, len(members))
+	for i, m := range members {
+		values[i] = m
+	}
+
+	result, err := c.rdb.ZRem(c.ctx, key, values...).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du ZREM",
+			zap.String("key", key),
+			zap.Strings("members", members),
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to ZREM from key %s: %w", key, err)
+	}
+	return result, nil
+}
+
+// ZRangeByScore retourne les membres dont le score est compris entre min et max
+// (bornes au format Redis : "-inf", "+inf", "(123"...). count <= 0 signifie sans limite.
+func (c *Client) ZRangeByScore(key string, min, max string, count int64) ([]string, error) {
+	opt := &redis.ZRangeBy{Min: min, Max: max}
+	if count > 0 {
+		opt.Count = count
+	}
+
+	result, err := c.rdb.ZRangeByScore(c.ctx, key, opt).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du ZRANGEBYSCORE",
+			zap.String("key", key),
+			zap.String("min", min),
+			zap.String("max", max),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to ZRANGEBYSCORE on key %s: %w", key, err)
+	}
+	return result, nil
+}
+
+// ZRevRange retourne les membres d'un sorted set du score le plus élevé au plus faible
+func (c *Client) ZRevRange(key string, start, stop int64) ([]string, error) {
+	result, err := c.rdb.ZRevRange(c.ctx, key, start, stop).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du ZREVRANGE",
+			zap.String("key", key),
+			zap.Int64("start", start),
+			zap.Int64("stop", stop),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to ZREVRANGE on key %s: %w", key, err)
+	}
+	return result, nil
+}
+
+// ZCard retourne le nombre de membres d'un sorted set
+func (c *Client) ZCard(key string) (int64, error) {
+	result, err := c.rdb.ZCard(c.ctx, key).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du ZCARD",
+			zap.String("key", key),
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to ZCARD on key %s: %w", key, err)
+	}
+	return result, nil
+}
+
+// ZRemRangeByRank retire les membres compris entre deux rangs (pour borner un historique)
+func (c *Client) ZRemRangeByRank(key string, start, stop int64) error {
+	err := c.rdb.ZRemRangeByRank(c.ctx, key, start, stop).Err()
+	if err != nil {
+		logger.Log.Error("Erreur lors du ZREMRANGEBYRANK",
+			zap.String("key", key),
+			zap.Int64("start", start),
+			zap.Int64("stop", stop),
+			zap.Error(err))
+		return fmt.Errorf("failed to ZREMRANGEBYRANK on key %s: %w", key, err)
+	}
+	return nil
+}
+
+// === OPÉRATIONS DE STREAMS POUR LES JOURNAUX D'EXÉCUTION ===
+
+// StreamEntry représente une entrée d'un stream Redis
+type StreamEntry struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// XAdd ajoute une entrée à la fin d'un stream et retourne son ID
+// maxLen borne approximativement la taille du stream (0 = pas de limite)
+func (c *Client) XAdd(key string, maxLen int64, values map[string]interface{}) (string, error) {
+	id, err := c.rdb.XAdd(c.ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: maxLen,
+		Approx: maxLen > 0,
+		Values: values,
+	}).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du XADD",
+			zap.String("key", key),
+			zap.Error(err))
+		return "", fmt.Errorf("failed to XADD on key %s: %w", key, err)
+	}
+	return id, nil
+}
+
+// XRange retourne les entrées d'un stream comprises entre deux IDs ("-" et "+" pour les bornes)
+// count limite le nombre d'entrées retournées (0 = toutes)
+func (c *Client) XRange(key, start, stop string, count int64) ([]StreamEntry, error) {
+	var messages []redis.XMessage
+	var err error
+	if count > 0 {
+		messages, err = c.rdb.XRangeN(c.ctx, key, start, stop, count).Result()
+	} else {
+		messages, err = c.rdb.XRange(c.ctx, key, start, stop).Result()
+	}
+	if err != nil {
+		logger.Log.Error("Erreur lors du XRANGE",
+			zap.String("key", key),
+			zap.String("start", start),
+			zap.String("stop", stop),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to XRANGE on key %s: %w", key, err)
+	}
+	return toStreamEntries(messages), nil
+}
+
+// XRead attend les entrées d'un stream postérieures à lastID (bloquant)
+// Retourne ErrTimeout si aucune entrée n'arrive avant le délai
+func (c *Client) XRead(key, lastID string, count int64, block time.Duration) ([]StreamEntry, error) {
+	streams, err := c.rdb.XRead(c.ctx, &redis.XReadArgs{
+		Streams: []string{key, lastID},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrTimeout
+		}
+		if isCancelled(err) {
+			return nil, err
+		}
+		logger.Log.Error("Erreur lors du XREAD",
+			zap.String("key", key),
+			zap.String("last_id", lastID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to XREAD on key %s: %w", key, err)
+	}
+
+	var entries []StreamEntry
+	for _, stream := range streams {
+		entries = append(entries, toStreamEntries(stream.Messages)...)
+	}
+	return entries, nil
+}
+
+func toStreamEntries(messages []redis.XMessage) []StreamEntry {
+	entries := make([]StreamEntry, 0, len(messages))
+	for _, message := range messages {
+		entries = append(entries, StreamEntry{ID: message.ID, Values: message.Values})
+	}
+	return entries
+}
+
+// === OPÉRATIONS DE HASHES ===
+
+// HSet définit la valeur d'un champ d'un hash
+func (c *Client) HSet(key, field string, value interface{}) error {
+	err := c.rdb.HSet(c.ctx, key, field, value).Err()
+	if err != nil {
+		logger.Log.Error("Erreur lors du HSET",
+			zap.String("key", key),
+			zap.String("field", field),
+			zap.Error(err))
+		return fmt.Errorf("failed to HSET %s on key %s: %w", field, key, err)
+	}
+	return nil
+}
+
+// HSetNX définit la valeur d'un champ d'un hash s'il n'existe pas encore
+// Retourne false si le champ existait déjà
+func (c *Client) HSetNX(key, field string, value interface{}) (bool, error) {
+	ok, err := c.rdb.HSetNX(c.ctx, key, field, value).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du HSETNX",
+			zap.String("key", key),
+			zap.String("field", field),
+			zap.Error(err))
+		return false, fmt.Errorf("failed to HSETNX %s on key %s: %w", field, key, err)
+	}
+	return ok, nil
+}
+
+// HGet récupère la valeur d'un champ d'un hash
+func (c *Client) HGet(key, field string) (string, error) {
+	val, err := c.rdb.HGet(c.ctx, key, field).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", fmt.Errorf("field %s not found in key %s", field, key)
+		}
+		logger.Log.Error("Erreur lors du HGET",
+			zap.String("key", key),
+			zap.String("field", field),
+			zap.Error(err))
+		return "", fmt.Errorf("failed to HGET %s on key %s: %w", field, key, err)
+	}
+	return val, nil
+}
+
+// HGetAll récupère tous les champs d'un hash
+func (c *Client) HGetAll(key string) (map[string]string, error) {
+	result, err := c.rdb.HGetAll(c.ctx, key).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du HGETALL",
+			zap.String("key", key),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to HGETALL on key %s: %w", key, err)
+	}
+	return result, nil
+}
+
+// HDel supprime un ou plusieurs champs d'un hash et retourne le nombre supprimé
+func (c *Client) HDel(key string, fields ...string) (int64, error) {
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("no fields provided for HDEL")
+	}
+
+	result, err := c.rdb.HDel(c.ctx, key, fields...).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du HDEL",
+			zap.String("key", key),
+			zap.Strings("fields", fields),
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to HDEL on key %s: %w", key, err)
+	}
+	return result, nil
+}
+
+// HIncrBy incrémente un champ numérique d'un hash et retourne sa nouvelle valeur
+func (c *Client) HIncrBy(key, field string, value int64) (int64, error) {
+	result, err := c.rdb.HIncrBy(c.ctx, key, field, value).Result()
+	if err != nil {
+		logger.Log.Error("Erreur lors du HINCRBY",
+			zap.String("key", key),
+			zap.String("field", field),
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to HINCRBY %s on key %s: %w", field, key, err)
+	}
+	return result, nil
+}
+
 // Increment incrémente une valeur numérique
 func (c *Client) Increment(key string) (int64, error) {
 	val, err := c.rdb.Incr(c.ctx, key).Result()
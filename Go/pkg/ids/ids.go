@@ -0,0 +1,19 @@
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// New returns a random, URL-safe identifier prefixed with the given kind
+// (e.g. "job_3f9c0a..."). Falls back to a timestamp if the system random
+// source is unavailable so callers never have to handle an error.
+func New(prefix string) string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	}
+	return prefix + "_" + hex.EncodeToString(buf)
+}
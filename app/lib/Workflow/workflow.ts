@@ -3,7 +3,7 @@
 // lib/actions/workflow.ts
 import { v4 as uuidv4 } from 'uuid';
 import httpClient  from '@/app/lib/httpClient/httpClient';
-import type { WorkflowNode, WorkflowEdge, SaveWorkflowResult } from '@/app/lib/types/types';
+import type { WorkflowNode, WorkflowEdge, SaveWorkflowResult, NodeTypeDefinition } from '@/app/lib/types/types';
 
 export async function saveWorkflow(formData: FormData): Promise<SaveWorkflowResult> {
   try {
@@ -42,9 +42,9 @@ export async function saveWorkflow(formData: FormData): Promise<SaveWorkflowResu
       };
     }
 
-    // 5. Préparation du payload
+    // 5. Préparation du payload (exécution interactive → priorité haute)
     const id = uuidv4();
-    const payload = { nodes, edges, id };
+    const payload = { nodes, edges, id, priority: 'high' };
 
     // 6. Appel API
     const response = await httpClient.post('/workflow', payload);
@@ -163,3 +163,26 @@ export async function getWorflow(id: string): Promise<SaveWorkflowResult> {
 }
 
 
+
+
+export async function getNodeTypes(): Promise<{ success: boolean; data?: NodeTypeDefinition[]; error?: string }> {
+  try {
+    const response = await httpClient.get('/node-types');
+
+    if (response.status === 200 && Array.isArray(response.data?.data)) {
+      return { success: true, data: response.data.data };
+    }
+
+    console.error('❌ Unexpected status:', response.status, response.data);
+    return { 
+      success: false, 
+      error: `Server returned status ${response.status}` 
+    };
+  } catch (error) {
+    console.error('❌ Error retrieving node types:', error);
+    return { 
+      success: false, 
+      error: error instanceof Error ? `Failed to retrieve node types: ${error.message}` : 'Failed to retrieve node types: Unknown error' 
+    };
+  }
+}
@@ -0,0 +1,40 @@
+import { useEffect, useState } from 'react';
+import { getNodeTypes } from '@/app/lib/Workflow/workflow';
+import type { NodeTypeDefinition } from '../types/types';
+
+// Valeurs par défaut d'une node, tirées des "default" de son schéma de configuration
+export function defaultNodeData(definition: NodeTypeDefinition): Record<string, any> {
+  const data: Record<string, any> = { label: definition.displayName };
+  const properties = definition.config?.properties ?? {};
+  for (const [name, property] of Object.entries<any>(properties)) {
+    if (property?.default !== undefined) {
+      data[name] = property.default;
+    }
+  }
+  return data;
+}
+
+// Charge le catalogue des types de nodes déclarés côté Go (via une server action)
+export function useNodeTypes() {
+  const [nodeTypes, setNodeTypes] = useState<NodeTypeDefinition[]>([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [error, setError] = useState<Error | null>(null);
+
+  useEffect(() => {
+    let cancelled = false;
+
+    getNodeTypes().then(result => {
+      if (cancelled) return;
+      if (result.success) {
+        setNodeTypes(result.data ?? []);
+      } else {
+        setError(new Error(result.error));
+      }
+      setIsLoading(false);
+    });
+
+    return () => { cancelled = true; };
+  }, []);
+
+  return { nodeTypes, isLoading, error };
+}
@@ -124,4 +124,24 @@ export interface ExecutionData {
   result?: any;
   logs?: string[];
   meta?: any;
-}
\ No newline at end of file
+}
+// 🎯 Catalogue des types de nodes (GET /node-types), source : registre Go nodetypes
+export interface NodeTypeHandle {
+  id: string;            // sourceHandle / targetHandle des edges
+  label: string;
+}
+
+export interface NodeTypeDefinition {
+  type: string;
+  displayName: string;
+  category: 'trigger' | 'action' | 'flow' | 'human' | string;
+  description?: string;
+  version: number;
+  trigger?: string;      // Type de déclencheur, absent pour les nodes normales
+  branches?: string[];
+  suspends?: boolean;
+  loop?: boolean;
+  config?: Record<string, any>;  // JSON Schema de node.data
+  inputs: NodeTypeHandle[];
+  outputs: NodeTypeHandle[];
+}
@@ -1,12 +1,15 @@
 import React, { useState, useCallback, useMemo, memo, DragEvent } from 'react';
 import { ReactFlowInstance } from '@xyflow/react';
-import { MdHttp, MdPlayArrow, MdTimer, MdExpandMore, MdExpandLess, MdDragIndicator } from 'react-icons/md';
+import { MdHttp, MdPlayArrow, MdTimer, MdBolt, MdPerson, MdExpandMore, MdExpandLess, MdDragIndicator } from 'react-icons/md';
+import { useNodeTypes, defaultNodeData } from '@/app/lib/hook/useNodeTypes';
+import type { NodeTypeDefinition } from '@/app/lib/types/types';
 
 interface NodeTemplate {
     type: string;
     label: string;
     icon: React.ReactNode;
     description: string;
+    category: string;
     defaultData: any;
     color: string;
     dimensions: {
@@ -22,36 +25,32 @@ interface NodePaletteProps {
     onToggle?: (isOpen: boolean) => void;
 }
 
-// Constantes en dehors du composant pour éviter les re-créations
-const NODE_TEMPLATES: NodeTemplate[] = [
-    {
-        type: 'manualStartNode',
-        label: 'Manual Start',
-        icon: <MdPlayArrow size={16} />,
-        description: 'Démarre le workflow manuellement',
-        defaultData: { label: 'Start' },
-        color: 'bg-zinc-700',
-        dimensions: { width: 320, height: 100 }
-    },
-    {
-        type: 'httpRequestNode',
-        label: 'HTTP Request',
-        icon: <MdHttp size={16} />,
-        description: 'Effectue une requête HTTP',
-        defaultData: { method: 'GET', url: 'https://api.example.com' },
-        color: 'bg-zinc-700',
-        dimensions: { width: 320, height: 140 }
-    },
-    {
-        type: 'waitingNode',
-        label: 'Wait',
-        icon: <MdTimer size={16} />,
-        description: 'Attend pendant une durée définie',
-        defaultData: { duration: '1000' },
-        color: 'bg-zinc-700',
-        dimensions: { width: 320, height: 120 }
-    }
-];
+// Icônes par type de node (les types inconnus de l'éditeur prennent celle de leur catégorie)
+const NODE_ICONS: Record<string, React.ReactNode> = {
+    manualStartNode: <MdPlayArrow size={16} />,
+    httpRequestNode: <MdHttp size={16} />,
+    waitingNode: <MdTimer size={16} />,
+};
+
+const CATEGORY_ICONS: Record<string, React.ReactNode> = {
+    trigger: <MdPlayArrow size={16} />,
+    action: <MdBolt size={16} />,
+    flow: <MdTimer size={16} />,
+    human: <MdPerson size={16} />,
+};
+
+// Les templates sont construits à partir du catalogue Go (GET /node-types) :
+// une node ajoutée au registre côté Go apparaît ici sans modification
+const toTemplate = (definition: NodeTypeDefinition): NodeTemplate => ({
+    type: definition.type,
+    label: definition.displayName,
+    icon: NODE_ICONS[definition.type] ?? CATEGORY_ICONS[definition.category] ?? <MdBolt size={16} />,
+    description: definition.description ?? '',
+    category: definition.category,
+    defaultData: defaultNodeData(definition),
+    color: 'bg-zinc-700',
+    dimensions: getNodeDimensions(definition.type)
+});
 
 // Fonction utilitaire pour obtenir les dimensions d'un node de manière dynamique
 const getNodeDimensions = (nodeType: string): { width: number; height: number } => {
@@ -162,20 +161,24 @@ export const NodePalette: React.FC<NodePaletteProps> = memo(({
 }) => {
     const [internalIsOpen, setInternalIsOpen] = useState(true);
     const [searchTerm, setSearchTerm] = useState('');
+    const { nodeTypes, isLoading, error } = useNodeTypes();
+
+    const templates = useMemo(() => nodeTypes.map(toTemplate), [nodeTypes]);
 
     const isOpen = controlledIsOpen !== undefined ? controlledIsOpen : internalIsOpen;
     const setIsOpen = onToggle || setInternalIsOpen;
 
     // Filtrage mémorisé avec debounce virtuel
     const filteredTemplates = useMemo(() => {
-        if (!searchTerm.trim()) return NODE_TEMPLATES;
+        if (!searchTerm.trim()) return templates;
 
         const term = searchTerm.toLowerCase();
-        return NODE_TEMPLATES.filter(template =>
+        return templates.filter(template =>
             template.label.toLowerCase().includes(term) ||
-            template.description.toLowerCase().includes(term)
+            template.description.toLowerCase().includes(term) ||
+            template.category.toLowerCase().includes(term)
         );
-    }, [searchTerm]);
+    }, [searchTerm, templates]);
 
     // Callbacks mémorisés
     const handleAddNode = useCallback((template: NodeTemplate) => {
@@ -265,7 +268,11 @@ export const NodePalette: React.FC<NodePaletteProps> = memo(({
                 {/* Liste des nodes optimisée */}
                 <div className="flex-1 overflow-y-auto node-palette-scrollbar">
                     <div className="p-3 space-y-2">
-                        {filteredTemplates.length > 0 ? (
+                        {isLoading ? (
+                            <p className="text-zinc-400 text-sm text-center py-8">Chargement...</p>
+                        ) : error ? (
+                            <p className="text-red-400 text-sm text-center py-8">Impossible de charger les nodes</p>
+                        ) : filteredTemplates.length > 0 ? (
                             filteredTemplates.map((template) => (
                                 <NodeItem
                                     key={template.type}